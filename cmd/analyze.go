@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/spf13/cobra"
+)
+
+var analyzeJSON bool
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "inspect node_modules content without removing anything",
+	Long: `Walks through directory without removing anything and splits its content by categories
+(runtime js, typescript sources, type declarations, source maps, docs, tests, examples, native binaries, images and other).
+
+Also shows how much space every built-in rule would release.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		shrinker := newShrinker(shrinkConfig(cmd))
+		analysis := shrinker.Analyze(cmd.Context())
+
+		if analyzeJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(analysis); err != nil {
+				fail("fail encode analysis", "error", err)
+			}
+			return
+		}
+
+		var buf bytes.Buffer
+		table := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(table, "CATEGORY\tSIZE\tFILES\tSHARE")
+		for _, category := range analysis.Categories {
+			fmt.Fprintf(table, "%s\t%s\t%d\t%s\n", category.Category, humanize.Bytes(uint64(category.Size)), category.FilesCount, share(category.Size, analysis.Size))
+		}
+		fmt.Fprintf(table, "total\t%s\t%d\t\n", humanize.Bytes(uint64(analysis.Size)), analysis.FilesCount)
+
+		fmt.Fprintln(table, "\t\t\t")
+		fmt.Fprintln(table, "BUILT-IN RULE\tSAVINGS\tFILES\tSHARE")
+		for _, rule := range analysis.Rules {
+			fmt.Fprintf(table, "%s (%s)\t%s\t%d\t%s\n", rule.Rule, rule.Kind, humanize.Bytes(uint64(rule.Size)), rule.FilesCount, share(rule.Size, analysis.Size))
+		}
+
+		_ = table.Flush()
+		printf("%s", buf.String())
+	},
+}
+
+func share(part, total int64) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+var analyzeDuplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "list packages installed in several versions",
+	Long: `Reads package.json of every installed package and lists packages installed in several versions with their pathes and sizes.
+
+Dependents of every version are taken from lockfile (npm-shrinkwrap.json, package-lock.json or node_modules/.package-lock.json) of the project.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		shrinker := newShrinker(shrinkConfig(cmd))
+		duplicates := shrinker.DuplicatePackages(cmd.Context())
+
+		var totalSize int64
+		for _, dp := range duplicates {
+			totalSize += dp.Size()
+
+			printf("%s (%d versions, %v)\n", color.Yellow(dp.Name), dp.VersionsCount(), color.Cyan(humanize.Bytes(uint64(dp.Size()))))
+			for _, instance := range dp.Instances {
+				instancePath, err := filepath.Rel(checkPath, instance.Path)
+				if err != nil {
+					instancePath = instance.Path
+				}
+
+				line := "    " + instance.Version + " " + instancePath + " (" + humanize.Bytes(uint64(instance.Size)) + ")"
+				if len(instance.Dependents) != 0 {
+					line += " <- " + strings.Join(instance.Dependents, ", ")
+				}
+				printLine(line)
+			}
+		}
+
+		printf("packages installed in several versions: %d, total size: %v\n", len(duplicates), color.Cyan(humanize.Bytes(uint64(totalSize))))
+	},
+}
+
+func init() {
+	analyzeCmd.Flags().BoolVar(&analyzeJSON, "json", false, "print result in JSON format")
+	analyzeCmd.AddCommand(analyzeDuplicatesCmd)
+
+	rootCmd.AddCommand(analyzeCmd)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var checkMaxSize string
+var checkMaxFiles int64
+var checkTopCount int
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "shrink directory and check that it fits size budget",
+	Long: `Shrinks directory (or only calculates result with --dry-run) and compares size and files count of the rest tree with budgets.
+
+Budgets are taken from --max-size/--max-files flags for checking directory and from "budgets" section of config file:
+
+budgets:
+  node_modules:
+    max_size: 150MB
+    max_files: 20000
+
+Budgets of config file are checked for directories inside of --dir only, if the flag is provided.
+Exits with non-zero code and prints the biggest packages if any budget is exceeded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		budgets := make(map[string]*shrink.Budget)
+		if fileConfig != nil {
+			onlyInCheckPath := cmd.Flags().Changed("dir")
+			for dir, budget := range fileConfig.ResolvedBudgets() {
+				if onlyInCheckPath && !isInsideDir(checkPath, dir) {
+					continue
+				}
+				budgets[dir] = budget
+			}
+		}
+
+		if checkMaxSize != "" || checkMaxFiles != 0 {
+			budget := &shrink.Budget{MaxFiles: checkMaxFiles}
+			if checkMaxSize != "" {
+				size, err := shrink.ParseByteSize(checkMaxSize)
+				if err != nil {
+					fail("fail parse --max-size value", "error", err)
+				}
+				budget.MaxSize = size
+			}
+			budgets[checkPath] = budget
+		}
+
+		if len(budgets) == 0 {
+			fail("no budgets provided, use --max-size/--max-files flags or budgets section of config file")
+		}
+
+		dirs := make([]string, 0, len(budgets))
+		for dir := range budgets {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		ctx := cmd.Context()
+
+		exceeded := false
+		for _, dir := range dirs {
+			cfg := shrinkConfig(cmd)
+			cfg.CheckPath = dir
+			shrinker := newShrinker(cfg)
+
+			if !dryRun {
+				shrinker.Clean(ctx)
+			}
+
+			measure := shrinker.Measure(ctx, dryRun)
+			violations := budgets[dir].Check(measure)
+
+			status := color.Green("OK")
+			if len(violations) != 0 {
+				status = color.Red("EXCEEDED")
+				exceeded = true
+			}
+			printf("%s: %v, %d files %v\n", dir, color.Cyan(humanize.Bytes(uint64(measure.Size))), measure.FilesCount, status)
+
+			for _, violation := range violations {
+				if violation.Metric == "size" {
+					printf("    size %s is over limit %s\n", humanize.Bytes(uint64(violation.Actual)), humanize.Bytes(uint64(violation.Limit)))
+				} else {
+					printf("    files count %d is over limit %d\n", violation.Actual, violation.Limit)
+				}
+			}
+
+			if len(violations) == 0 {
+				continue
+			}
+
+			printLine("    the biggest packages:")
+			for i, pkg := range measure.Packages {
+				if i == checkTopCount {
+					break
+				}
+
+				name := pkg.Name
+				if name == "" {
+					name = rootPackageTitle
+				}
+				printf("    %s (%v, %d files)\n", name, color.Cyan(humanize.Bytes(uint64(pkg.Size))), pkg.FilesCount)
+			}
+		}
+
+		if exceeded {
+			os.Exit(1)
+		}
+	},
+}
+
+// Checks is path the directory itself or inside of it
+func isInsideDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	relPath, err := filepath.Rel(absDir, absPath)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkMaxSize, "max-size", "", "maximum size of directory after shrinking (e.g. 150MB)")
+	checkCmd.Flags().Int64Var(&checkMaxFiles, "max-files", 0, "maximum files count of directory after shrinking")
+	checkCmd.Flags().IntVar(&checkTopCount, "top", 10, "count of the biggest packages printed when budget is exceeded")
+
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var configInitForce, configValidateNoTree bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "create and check config file",
+	// config file can be missing or broken here, so it isn't loaded before subcommands
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		logger = newLogger()
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "write starter config file with default rules",
+	Long: `Writes config file (` + shrink.DefaultConfigFileName + ` in current directory or path from --config flag) with default
+rules and comments about every section. Existing file is kept unless --force flag is provided.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := configPath()
+		if _, err := os.Stat(path); err == nil && !configInitForce {
+			fail("config file already exists, use --force to overwrite it", "path", path)
+		}
+
+		if err := ioutil.WriteFile(path, shrink.ConfigSkeleton(), 0644); err != nil {
+			fail("fail write config file", "error", err)
+		}
+		printf("Created config file %s\n", path)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "check config file for mistakes",
+	Long: `Parses config file (` + shrink.DefaultConfigFileName + ` in current directory or path from --config flag) and compiles every pattern.
+Reports unknown keys, invalid regular expressions, duplicated rules, include rules overridden by exclude ones
+and rules which don't match any entry of checking directory (skipped with --no-tree or if directory doesn't exist).
+
+Exits with non-zero code if any problem is found.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := configPath()
+		validation, err := shrink.ValidateFileConfig(fs.NewFS(), path)
+		if err != nil {
+			fail("fail read config file", "error", err)
+		}
+
+		if !configValidateNoTree {
+			if prepareCheckPath() {
+				shrinker := newShrinker(shrinkConfig(cmd))
+				validation.AddUnmatched(shrinker.UnmatchedRules(cmd.Context(), validation.Rules()))
+			} else {
+				printLine(color.Yellow("Rules aren't checked against directory tree"))
+			}
+		}
+
+		if len(validation.Problems) == 0 {
+			printf("%s: %v\n", path, color.Green("no problems found"))
+			return
+		}
+
+		for _, problem := range validation.Problems {
+			printf("%s:%d: %v: %s\n", path, problem.Line, color.Red(problem.Kind), problem.Message)
+		}
+		printf("found %d problems\n", len(validation.Problems))
+		os.Exit(1)
+	},
+}
+
+// Returns path of config file from --config flag or default one
+func configPath() string {
+	if configFile != "" {
+		return configFile
+	}
+	return shrink.DefaultConfigFileName
+}
+
+func init() {
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "overwrite existing config file")
+	configValidateCmd.Flags().BoolVar(&configValidateNoTree, "no-tree", false, "don't check rules against entries of checking directory")
+
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
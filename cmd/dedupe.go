@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"runtime"
+
+	"github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var linkMode string
+var jobs int
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "replace byte-identical files with links to single copy",
+	Long: `Searches files with identical content (e.g. from duplicate package versions) and replaces duplicates with links.
+
+Link modes:
+  auto     - reflinks where file system supports them, hardlinks otherwise
+  hardlink - always hardlinks
+  reflink  - only reflinks, other files are left untouched
+
+Files matched by exclude rules are not linked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		mode, err := shrink.ParseLinkMode(linkMode)
+		if err != nil {
+			fail("unknown link mode", "mode", linkMode)
+		}
+
+		cfg := shrinkConfig(cmd)
+		cfg.LinkMode = mode
+		cfg.ConcurentLimit = jobs
+		shrinker := newShrinker(cfg)
+
+		printf("Start dedupe directory %s\n", checkPath)
+
+		ctx := cmd.Context()
+
+		var stats *fs.FileStat
+		if dryRun {
+			stats = shrinker.DedupeDryRun(ctx)
+			printLine("Dry-run stats:")
+			printf("space to reclaim: %v\n", color.Cyan(humanize.Bytes(uint64(stats.Size()))))
+			printf("files count to link: %d\n", color.Cyan(stats.FilesCount()))
+		} else {
+			stats = shrinker.Dedupe(ctx)
+			printLine("Dedupe stats:")
+			printf("reclaimed space: %v\n", color.Cyan(humanize.Bytes(uint64(stats.Size()))))
+			printf("linked files count: %d\n", color.Cyan(stats.FilesCount()))
+		}
+	},
+}
+
+func init() {
+	dedupeCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "count of parallel workers hashing and linking files")
+	dedupeCmd.Flags().StringVar(&linkMode, "link", "auto", "how duplicates are replaced: auto, hardlink or reflink")
+
+	rootCmd.AddCommand(dedupeCmd)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var snapshotOutput, largeFileSize string
+var diffJSON bool
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "save summary of directory tree for comparing it later with diff command",
+	Long: `Walks directory without removing anything and saves its size by packages, installed versions
+and the largest files in JSON format. Snapshot is printed to stdout if --output isn't provided.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		snapshot := takeSnapshot(cmd.Context(), checkPath)
+
+		var out io.Writer = os.Stdout
+		if snapshotOutput != "" {
+			file, err := os.Create(snapshotOutput)
+			if err != nil {
+				fail("fail create snapshot file", "error", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err := snapshot.Write(out); err != nil {
+			fail("fail write snapshot", "error", err)
+		}
+	},
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <before> <after>",
+	Short: "compare two directories or snapshots",
+	Long: `Shows added and removed packages, size change of every package and new large files.
+Every argument is either directory or snapshot file saved by snapshot command, e.g.
+
+node_shrinker snapshot -d node_modules -o before.json
+npm update
+node_shrinker diff before.json node_modules`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		threshold := parseLargeFileSize()
+
+		ctx := cmd.Context()
+		before := loadSnapshot(ctx, args[0])
+		after := loadSnapshot(ctx, args[1])
+
+		diff := shrink.DiffSnapshots(before, after, threshold)
+
+		if diffJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(diff); err != nil {
+				fail("fail encode diff", "error", err)
+			}
+			return
+		}
+
+		printf("size: %s -> %s (%v)\n", humanize.Bytes(uint64(diff.SizeBefore)), humanize.Bytes(uint64(diff.SizeAfter)), colorSizeDelta(diff.SizeDelta()))
+		printf("files: %d -> %d (%+d)\n", diff.FilesBefore, diff.FilesAfter, diff.FilesDelta())
+
+		if len(diff.Added)+len(diff.Removed)+len(diff.Changed) != 0 {
+			var buf bytes.Buffer
+			table := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+			fmt.Fprintln(table, "\tPACKAGE\tVERSIONS\tSIZE\tCHANGE\tFILES")
+			for _, pkg := range diff.Added {
+				fmt.Fprintf(table, "+\t%s\t%s\t%s\t%s\t%+d\n", packageTitle(pkg.Name), strings.Join(pkg.Versions, ", "), humanize.Bytes(uint64(pkg.Size)), formatSizeDelta(pkg.Size), pkg.FilesCount)
+			}
+			for _, pkg := range diff.Removed {
+				fmt.Fprintf(table, "-\t%s\t%s\t%s\t%s\t%+d\n", packageTitle(pkg.Name), strings.Join(pkg.Versions, ", "), humanize.Bytes(0), formatSizeDelta(-pkg.Size), -pkg.FilesCount)
+			}
+			for _, pkg := range diff.Changed {
+				versions := strings.Join(pkg.VersionsAfter, ", ")
+				if before := strings.Join(pkg.VersionsBefore, ", "); before != versions {
+					versions = before + " -> " + versions
+				}
+				fmt.Fprintf(table, "~\t%s\t%s\t%s\t%s\t%+d\n", packageTitle(pkg.Name), versions, humanize.Bytes(uint64(pkg.SizeAfter)), formatSizeDelta(pkg.SizeDelta()), pkg.FilesDelta())
+			}
+
+			_ = table.Flush()
+			printLine()
+			printf("%s", buf.String())
+		}
+
+		if len(diff.NewLargeFiles) != 0 {
+			printLine()
+			printf("new files larger than %s:\n", humanize.Bytes(uint64(threshold)))
+			for _, file := range diff.NewLargeFiles {
+				printf("    %s (%v)\n", file.Path, color.Cyan(humanize.Bytes(uint64(file.Size))))
+			}
+		}
+	},
+}
+
+func parseLargeFileSize() int64 {
+	size, err := shrink.ParseByteSize(largeFileSize)
+	if err != nil {
+		fail("fail parse --large-file-size value", "error", err)
+	}
+	return int64(size)
+}
+
+func takeSnapshot(ctx context.Context, path string) *shrink.Snapshot {
+	snapshotter, err := shrink.NewSnapshotter(&shrink.SnapshotConfig{
+		VerboseOutput: verboseOutput,
+		Logger:        logger,
+		Path:          path,
+		LargeFileSize: parseLargeFileSize(),
+	})
+	if err != nil {
+		fail("something has broken", "error", err)
+	}
+
+	snapshot, err := snapshotter.Snapshot(ctx)
+	if err != nil {
+		fail("fail walk", "path", path, "error", err)
+	}
+	return snapshot
+}
+
+// Takes snapshot of directory or reads snapshot file
+func loadSnapshot(ctx context.Context, path string) *shrink.Snapshot {
+	if isDir, err := isDirectoryExists(path); err == nil && isDir {
+		return takeSnapshot(ctx, path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fail("fail open snapshot", "error", err)
+	}
+	defer file.Close()
+
+	snapshot, err := shrink.ReadSnapshot(file)
+	if err != nil {
+		fail("fail read snapshot", "path", path, "error", err)
+	}
+	return snapshot
+}
+
+func packageTitle(name string) string {
+	if name == "" {
+		return rootPackageTitle
+	}
+	return name
+}
+
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + humanize.Bytes(uint64(-delta))
+	}
+	return "+" + humanize.Bytes(uint64(delta))
+}
+
+func colorSizeDelta(delta int64) color.Value {
+	if delta > 0 {
+		return color.Red(formatSizeDelta(delta))
+	}
+	return color.Green(formatSizeDelta(delta))
+}
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "path to snapshot file")
+	snapshotCmd.Flags().StringVar(&largeFileSize, "large-file-size", "1MB", "files with this size or bigger are listed in snapshot")
+
+	diffCmd.Flags().StringVar(&largeFileSize, "large-file-size", "1MB", "minimum size of reported new files")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "print diff in JSON format")
+
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(diffCmd)
+}
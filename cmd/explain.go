@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <path>...",
+	Short: "explain why path would or would not be removed",
+	Long: `Shows which rule (include name, regexp, extension or exclude) decides fate of provided pathes
+and where the rule came from (flag, config file or default set).
+
+With --verbose every checked entry from directory root down to the path is printed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		absCheckPath, err := filepath.Abs(checkPath)
+		if err != nil {
+			fail("fail resolve path", "error", err)
+		}
+		checkPath = absCheckPath
+
+		shrinker := newShrinker(shrinkConfig(cmd))
+
+		failed := false
+		for _, arg := range args {
+			path, err := filepath.Abs(arg)
+			if err != nil {
+				logger.Error("fail resolve path", "path", arg, "error", err)
+				failed = true
+				continue
+			}
+
+			explanation, err := shrinker.Explain(path)
+			if err != nil {
+				if errors.Is(err, shrink.OutsidePathError) {
+					logger.Error("path is outside of checking directory", "path", path, "dir", checkPath)
+				} else {
+					logger.Error("fail explain", "path", path, "error", err)
+				}
+				failed = true
+				continue
+			}
+
+			if explanation.Removed {
+				printLine(color.Red(explanation.String()))
+			} else {
+				printLine(color.Green(explanation.String()))
+			}
+
+			if verboseOutput {
+				for _, step := range explanation.Steps {
+					printf("    %s: %s\n", step.Path, step.Match)
+				}
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	addConditionFlags(explainCmd)
+
+	rootCmd.AddCommand(explainCmd)
+}
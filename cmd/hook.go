@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var installHookCmd = &cobra.Command{
+	Use:   "install-hook",
+	Short: "run node_shrinker after every npm install of the project",
+	Long: `Adds node_shrinker to postinstall script in package.json of the project (current directory by default),
+so node_modules is shrunk after every install with the project config. Existing postinstall script is kept
+and node_shrinker is chained after it. Order of keys and formatting of package.json are kept.
+
+Also creates ` + shrink.DefaultConfigFileName + ` with default rules if the project has no config file.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+		projectPath := shrink.ProjectPath(checkPath)
+
+		hookConfigFile, err := projectConfigFile(projectPath)
+		if err != nil {
+			fail("fail resolve config file path", "error", err)
+		}
+
+		err = editPackageManifest(projectPath, func(data []byte) ([]byte, error) {
+			return shrink.AddHook(data, shrink.HookCommand(hookConfigFile))
+		})
+		switch {
+		case errors.Is(err, shrink.HookExistsError):
+			printf("%s script already runs node_shrinker, package.json isn't changed\n", shrink.HookScriptName)
+		case err != nil:
+			fail("fail add script", "script", shrink.HookScriptName, "error", err)
+		default:
+			printf("Added %v to %s script\n", color.Cyan(shrink.HookCommand(hookConfigFile)), shrink.HookScriptName)
+		}
+
+		configPath := filepath.Join(projectPath, shrink.DefaultConfigFileName)
+		if hookConfigFile != "" {
+			configPath = filepath.Join(projectPath, hookConfigFile)
+		}
+		if _, err := os.Stat(configPath); err == nil {
+			return
+		}
+		if err := ioutil.WriteFile(configPath, shrink.ConfigSkeleton(), 0644); err != nil {
+			fail("fail create config file", "error", err)
+		}
+		printf("Created config file %s\n", configPath)
+	},
+}
+
+var uninstallHookCmd = &cobra.Command{
+	Use:   "uninstall-hook",
+	Short: "stop running node_shrinker after npm install of the project",
+	Long: `Removes node_shrinker from postinstall script in package.json of the project (current directory by default).
+Other commands of the script are kept, empty script is removed. Config file isn't removed.
+node_shrinker chained with || or ; isn't removed, because it would change meaning of the script, edit it manually then.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+		projectPath := shrink.ProjectPath(checkPath)
+
+		err := editPackageManifest(projectPath, shrink.RemoveHook)
+		switch {
+		case errors.Is(err, shrink.HookNotFoundError):
+			printf("%s script doesn't run node_shrinker, package.json isn't changed\n", shrink.HookScriptName)
+		case err != nil:
+			fail("fail remove script", "script", shrink.HookScriptName, "error", err)
+		default:
+			printf("Removed node_shrinker from %s script\n", shrink.HookScriptName)
+		}
+	},
+}
+
+// Returns --config flag value relative to project directory, empty string for default config file
+func projectConfigFile(projectPath string) (string, error) {
+	if configFile == "" {
+		return "", nil
+	}
+
+	absConfigFile, err := filepath.Abs(configFile)
+	if err != nil {
+		return "", err
+	}
+	absProjectPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err := filepath.Rel(absProjectPath, absConfigFile)
+	if err != nil {
+		return "", err
+	}
+	if relPath == shrink.DefaultConfigFileName {
+		return "", nil
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+// Rewrites package.json of project with edited content keeping file mode. Content is written to temporary
+// file which replaces package.json, so the manifest isn't left truncated on errors
+func editPackageManifest(projectPath string, edit func(data []byte) ([]byte, error)) error {
+	path := filepath.Join(projectPath, shrink.PackageManifestName)
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if data, err = edit(data); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(projectPath, "."+shrink.PackageManifestName+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(stat.Mode().Perm())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(installHookCmd)
+	rootCmd.AddCommand(uninstallHookCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/icecream78/node_shrinker/tui"
+	"github.com/spf13/cobra"
+)
+
+const rootPackageTitle string = "(project files)"
+
+var interactiveCmd = &cobra.Command{
+	Use:   "interactive",
+	Short: "choose files for removing in terminal UI (Linux only)",
+	Long: `Shows packages and matched files/directories sorted by size and lets you choose what to remove.
+
+Without include rules default rules are used for searching candidates. Nothing is selected initially.
+Terminal UI is supported on Linux only, on other platforms the command exits with error before removing anything.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		cfg := shrinkConfig(cmd)
+		if !hasRemoveRules(cfg) {
+			cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleInclude, shrink.SourceDefault, shrink.DefaultRemoveDirNames)...)
+			cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleExtension, shrink.SourceDefault, shrink.DefaultRemoveFileExt)...)
+		}
+		shrinker := newShrinker(cfg)
+
+		ctx := cmd.Context()
+
+		printf("Searching candidates in %s\n", checkPath)
+		candidates := shrinker.Candidates(ctx)
+
+		selector := tui.NewSelector(fmt.Sprintf("node_shrinker: %s", checkPath), candidatesToGroups(candidates))
+		if err := tui.Run(os.Stdin, os.Stdout, selector); err != nil {
+			fail("fail run interactive mode", "error", err)
+		}
+
+		if !selector.Confirmed() {
+			printLine("Nothing removed")
+			return
+		}
+
+		selected := make([]*shrink.Candidate, 0)
+		for _, entry := range selector.Selected() {
+			selected = append(selected, entry.Value.(*shrink.Candidate))
+		}
+
+		stats := shrinker.Remove(ctx, selected)
+		printStats(stats, false)
+	},
+}
+
+func candidatesToGroups(candidates []*shrink.Candidate) []*tui.Group {
+	groups := make([]*tui.Group, 0)
+	groupsByPackage := make(map[string]*tui.Group)
+
+	for _, candidate := range candidates {
+		title := candidate.Package
+		if title == "" {
+			title = rootPackageTitle
+		}
+
+		group, exists := groupsByPackage[title]
+		if !exists {
+			group = &tui.Group{Title: title}
+			groupsByPackage[title] = group
+			groups = append(groups, group)
+		}
+
+		entryTitle, err := filepath.Rel(checkPath, candidate.Path)
+		if err != nil {
+			entryTitle = candidate.Path
+		}
+		if candidate.IsDir {
+			entryTitle += string(filepath.Separator)
+		}
+
+		group.Entries = append(group.Entries, &tui.Entry{
+			Title: entryTitle,
+			Size:  candidate.Size,
+			Value: candidate,
+		})
+	}
+	return groups
+}
+
+func init() {
+	addConditionFlags(interactiveCmd)
+
+	rootCmd.AddCommand(interactiveCmd)
+}
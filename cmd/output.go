@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/icecream78/node_shrinker/shrink"
+)
+
+// Reports for user (stats, trees, tables, results of commands) are written to stdout as text.
+// They aren't log entries, so --log-format doesn't change them. Errors and diagnostics go through logger to stderr
+var out io.Writer = os.Stdout
+
+func printf(format string, args ...interface{}) {
+	fmt.Fprintf(out, format, args...)
+}
+
+func printLine(args ...interface{}) {
+	fmt.Fprintln(out, args...)
+}
+
+// Reports error through logger and exits. Errors which happen before logger is created are written to stderr as text
+func fail(msg string, keysAndValues ...interface{}) {
+	if logger == nil {
+		logger, _ = shrink.NewLogger(os.Stderr, shrink.LogFormatText, shrink.LevelError)
+	}
+	logger.Error(msg, keysAndValues...)
+	os.Exit(1)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/icecream78/node_shrinker/tui"
+)
+
+// how often progress line is printed when output isn't a terminal
+const progressLinesInterval time.Duration = 5 * time.Second
+
+// Prints progress in place on terminal and as periodic lines otherwise, e.g. in CI logs
+type progressPrinter struct {
+	out        *os.File
+	isTerminal bool
+	last       shrink.Progress
+	printedAt  time.Time
+}
+
+func newProgressPrinter(out *os.File) *progressPrinter {
+	return &progressPrinter{out: out, isTerminal: tui.IsTerminal(out)}
+}
+
+func (p *progressPrinter) Print(progress shrink.Progress) {
+	now := time.Now()
+	if !p.isTerminal && !progress.Done && now.Sub(p.printedAt) < progressLinesInterval {
+		return
+	}
+
+	line := p.format(progress)
+	if p.isTerminal {
+		fmt.Fprintf(p.out, "\r\x1b[K%s", line)
+		if progress.Done {
+			fmt.Fprintln(p.out)
+		}
+	} else {
+		fmt.Fprintln(p.out, line)
+	}
+
+	p.last = progress
+	p.printedAt = now
+}
+
+// Formats counters with rate since previous printed line
+func (p *progressPrinter) format(progress shrink.Progress) string {
+	scanRate, sizeRate := progress.ScanRate(), progress.SizeRate()
+	if elapsed := (progress.Elapsed - p.last.Elapsed).Seconds(); elapsed > 0 && !progress.Done {
+		scanRate = float64(progress.Scanned-p.last.Scanned) / elapsed
+		sizeRate = float64(progress.Size-p.last.Size) / elapsed
+	}
+
+	return fmt.Sprintf("scanned %s, matched %s, removed %s, freed %s (%s entries/s, %s/s)",
+		humanize.Comma(progress.Scanned),
+		humanize.Comma(progress.Matched),
+		humanize.Comma(progress.Removed),
+		humanize.Bytes(uint64(progress.Size)),
+		humanize.Comma(int64(scanRate)),
+		humanize.Bytes(uint64(sizeRate)),
+	)
+}
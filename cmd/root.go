@@ -1,10 +1,12 @@
 package cmd
 
 import (
-	"errors"
-	"log"
+	"bytes"
+	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/dustin/go-humanize"
 	color "github.com/logrusorgru/aurora"
@@ -14,9 +16,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var dryRun, verboseOutput, isNodeDir bool
-var checkPath string
-var excludeNames, includeNames, includeExtensions []string
+var dryRun, verboseOutput, isNodeDir, showRuleStats, showProgress, useCache bool
+var checkPath, noticesFile, configFile, logFormat, logLevel, targetPlatform string
+var excludeNames, includeNames, includeExtensions, presetNames []string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -26,51 +28,44 @@ var rootCmd = &cobra.Command{
 
 Utility was developed with CI/CD integration in mind.
 You can fully configure utility logic by various flags which are chainable or with .yml file with the same setting`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		logger = newLogger()
+		fileConfig = loadFileConfig()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if checkPath == "" {
-			cwd, err := os.Getwd()
-			if err != nil {
-				log.Fatal("Fail get current directory")
-			}
-			checkPath = cwd
-		}
-
-		if isNodeDir {
-			checkPath = path.Join(checkPath, "node_modules")
+		if !prepareCheckPath() {
+			return
 		}
 
-		if exists, err := isDirectoryExists(checkPath); err != nil {
-			if errors.Is(err, ProvidedFileError) {
-				log.Println("Provided specific file, not a path to directory for clean up. Shut down...")
+		if noticesFile != "" {
+			absNoticesFile, err := filepath.Abs(noticesFile)
+			if err != nil {
+				logger.Error("fail resolve notices file path", "error", err)
 				return
 			}
-
-			log.Printf("Fail to check path existence with error: %s\n", err.Error())
-			return
-		} else if !exists {
-			log.Println("Provided non exist path. Shut down...")
-			return
+			noticesFile = absNoticesFile
 		}
 
-		shrinker, err := shrink.NewShrinker(&shrink.Config{
-			CheckPath:     checkPath,
-			VerboseOutput: verboseOutput,
-			ExcludeNames:  excludeNames,
-			IncludeNames:  includeNames,
-			RemoveFileExt: includeExtensions,
-		})
-
-		if err != nil {
-			if errors.Is(err, shrink.NotExistError) {
-				log.Printf("Path %s doesn`t exist\n", checkPath)
-				os.Exit(1)
+		if useCache {
+			absCheckPath, err := filepath.Abs(checkPath)
+			if err != nil {
+				logger.Error("fail resolve path", "error", err)
+				return
 			}
+			checkPath = absCheckPath
+		}
 
-			log.Printf("Something has broken. Error: %v\n", err)
-			os.Exit(1)
+		cfg := shrinkConfig(cmd)
+		cfg.NoticesFile = noticesFile
+		if useCache && !dryRun {
+			cfg.CacheFile = shrink.DefaultCachePath(checkPath)
 		}
+		if showProgress && !dryRun {
+			cfg.OnProgress = newProgressPrinter(os.Stderr).Print
+		}
+		shrinker := newShrinker(cfg)
 
-		log.Printf("Start process directory %s\n", checkPath)
+		printf("Start process directory %s\n", checkPath)
 
 		ctx := cmd.Context()
 
@@ -81,39 +76,82 @@ You can fully configure utility logic by various flags which are chainable or wi
 			stats = shrinker.Clean(ctx)
 		}
 
-		if err != nil {
-			log.Printf("Fail make a job. Error: %v\n", err)
-			os.Exit(1)
+		printStats(stats, dryRun)
+		if showRuleStats {
+			printRuleStats(shrinker.RuleStats())
 		}
+	},
+}
 
-		if dryRun {
-			log.Println("Dry-run stats:")
-			log.Printf("space to release: %v\n", color.Cyan(humanize.Bytes(uint64(stats.Size()))))
-			log.Printf("files count to remove: %d\n", color.Cyan(stats.FilesCount()))
-		} else {
-			log.Println("Remove stats:")
-			log.Printf("released space: %v\n", color.Cyan(humanize.Bytes(uint64(stats.Size()))))
-			log.Printf("files count: %d\n", color.Cyan(stats.FilesCount()))
+func printStats(stats *fs.FileStat, dryRun bool) {
+	if dryRun {
+		printLine("Dry-run stats:")
+		printf("space to release: %v\n", color.Cyan(humanize.Bytes(uint64(stats.Size()))))
+		printf("files count to remove: %d\n", color.Cyan(stats.FilesCount()))
+	} else {
+		printLine("Remove stats:")
+		printf("released space: %v\n", color.Cyan(humanize.Bytes(uint64(stats.Size()))))
+		printf("files count: %d\n", color.Cyan(stats.FilesCount()))
+	}
+}
+
+func printRuleStats(stats []*shrink.RuleStat) {
+	var buf bytes.Buffer
+	table := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(table, "RULE\tKIND\tSOURCE\tMATCHED\tREMOVED\tSIZE\tFILES")
+	unmatched := make([]*shrink.Rule, 0)
+	for _, stat := range stats {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%s\t%d\n", stat.Rule.Pattern, stat.Rule.Kind, stat.Rule.Source, stat.Matched, stat.Removed, humanize.Bytes(uint64(stat.Size)), stat.FilesCount)
+		if stat.Matched == 0 {
+			unmatched = append(unmatched, stat.Rule)
 		}
-	},
+	}
+
+	_ = table.Flush()
+	printLine("Rule stats:")
+	printf("%s", buf.String())
+
+	if len(unmatched) > 0 {
+		printLine(color.Yellow("Rules that never matched (check them for typos):"))
+		for _, rule := range unmatched {
+			printf("    %s\n", color.Yellow(rule.String()))
+		}
+	}
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Println(err)
-		os.Exit(1)
+		fail("fail run command", "error", err)
 	}
 }
 
 func init() {
-	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
+	rootCmd.Long += "\n\nPresets for --preset flag:"
+	for _, preset := range shrink.Presets() {
+		rootCmd.Long += fmt.Sprintf("\n  %-12s %s", preset.Name, preset.Description)
+	}
 
 	rootCmd.PersistentFlags().StringVarP(&checkPath, "dir", "d", "", "path to directory where need cleanup")
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "path to config file. By default "+shrink.DefaultConfigFileName+" from current directory is used if it exists")
 	rootCmd.PersistentFlags().StringSliceVarP(&excludeNames, "exclude", "e", []string{}, "list of files/directories that should not be removed. Flag can be specified multiple times. Support regular expression syntax")
 	rootCmd.PersistentFlags().StringSliceVarP(&includeNames, "include", "i", []string{}, "list of files/directories that should be included in remove list. Flag can be specified multiple times. Support regular expression syntax")
 	rootCmd.PersistentFlags().StringSliceVarP(&includeExtensions, "ext", "x", []string{}, "list of file extensions that should be removed. Flag can be specified multiple times")
 
+	rootCmd.PersistentFlags().StringSliceVar(&presetNames, "preset", []string{}, "built-in rule bundle: "+strings.Join(shrink.PresetNames(), ", ")+". Flag can be specified multiple times, presets are combined with other rules")
+
+	rootCmd.PersistentFlags().StringVar(&targetPlatform, "platform", "", "platform where packages run (linux, darwin, win32), presets removing native binaries keep binaries of it. Overrides platform of config file, platform of current OS is used by default")
+
+	rootCmd.PersistentFlags().StringVar(&noticesFile, "notices", "", "path to file (e.g. "+shrink.DefaultNoticesFileName+") where license texts and package.json license fields of all packages are collected before removing")
+
 	rootCmd.PersistentFlags().BoolVarP(&verboseOutput, "verbose", "v", false, "more detailed output")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", string(shrink.LogFormatText), "format of log entries written to stderr: text or json. Reports (stats, trees, tables) are written to stdout as text and --progress counters to stderr, this flag doesn't change them")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "minimal level of log entries: debug, info, warn or error. By default debug with --verbose and error without it")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "display what files will be removed")
 	rootCmd.PersistentFlags().BoolVar(&isNodeDir, "node", false, "need detect node_modules dir")
+
+	addConditionFlags(rootCmd)
+	rootCmd.Flags().BoolVar(&showProgress, "progress", false, "show counters of scanned, matched and removed entries during cleanup. Refreshed in place on terminal and printed every "+progressLinesInterval.String()+" otherwise")
+	rootCmd.Flags().BoolVar(&useCache, "cache", false, "skip packages which weren't changed since previous run with the same rules. State is kept in node_modules/"+shrink.DefaultCacheFileName)
+	rootCmd.Flags().BoolVar(&showRuleStats, "rule-stats", false, "print how many entries every rule matched and how much space it released")
 }
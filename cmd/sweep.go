@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
+)
+
+var sweepOlderThan string
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep [root]",
+	Short: "find node_modules directories of all projects under root and remove stale ones",
+	Long: `Searches node_modules directories under root directory (current directory by default) and prints their sizes
+with last modification time of package.json near them. Nested node_modules directories are not walked.
+
+With --older-than flag removes whole node_modules directories of projects which weren't modified during provided period (e.g. 30d, 2w, 12h)`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			checkPath = args[0]
+		}
+
+		if !prepareCheckPath() {
+			return
+		}
+
+		var olderThan time.Duration
+		if sweepOlderThan != "" {
+			var err error
+			if olderThan, err = shrink.ParseDuration(sweepOlderThan); err != nil {
+				fail("fail parse --older-than value", "error", err)
+			}
+		}
+
+		sweeper, err := shrink.NewSweeper(&shrink.SweepConfig{
+			VerboseOutput: verboseOutput,
+			Logger:        logger,
+			RootPath:      checkPath,
+		})
+		if err != nil {
+			fail("something has broken", "error", err)
+		}
+
+		ctx := cmd.Context()
+
+		printf("Searching node_modules directories in %s\n", checkPath)
+		projects, err := sweeper.Projects(ctx)
+		if err != nil {
+			fail("fail search projects", "error", err)
+		}
+
+		now := time.Now()
+		stale := make([]*shrink.Project, 0)
+		var totalSize int64
+		for _, project := range projects {
+			totalSize += project.Size
+
+			var mark interface{} = ""
+			if sweepOlderThan != "" && project.IsStale(olderThan, now) {
+				stale = append(stale, project)
+				mark = color.Red(" stale")
+			}
+
+			printf("%s (%v) modified %s%v\n", project.Path, color.Cyan(humanize.Bytes(uint64(project.Size))), humanize.Time(project.ModTime), mark)
+		}
+		printf("found %d node_modules directories, total size: %v\n", len(projects), color.Cyan(humanize.Bytes(uint64(totalSize))))
+
+		if sweepOlderThan == "" {
+			return
+		}
+
+		if dryRun {
+			var staleSize, staleCount int64
+			for _, project := range stale {
+				staleSize += project.Size
+				staleCount += project.FilesCount
+			}
+			printf("stale node_modules directories: %d\n", len(stale))
+			printStats(fs.NewFileStat("result", "result", staleSize, staleCount), true)
+			return
+		}
+
+		stats := sweeper.Sweep(ctx, stale)
+		printStats(stats, false)
+	},
+}
+
+func init() {
+	sweepCmd.Flags().StringVar(&sweepOlderThan, "older-than", "", "remove node_modules of projects which weren't modified during this period (e.g. 30d, 2w, 12h)")
+
+	rootCmd.AddCommand(sweepCmd)
+}
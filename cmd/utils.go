@@ -4,12 +4,26 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"strings"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/spf13/cobra"
 )
 
 var (
 	ProvidedFileError error = errors.New("provided file not directory")
 )
 
+// annotation of commands which register size and age conditions flags with addConditionFlags
+const conditionsAnnotation = "node_shrinker_conditions"
+
+// loaded before any command is run, nil if there is no config file
+var fileConfig *shrink.FileConfig
+
+// created before any command is run from --log-format and --log-level flags
+var logger shrink.Logger
+
 func isDirectoryExists(path string) (bool, error) {
 	stats, err := os.Stat(path)
 	if err != nil {
@@ -21,3 +35,194 @@ func isDirectoryExists(path string) (bool, error) {
 	}
 	return true, nil
 }
+
+// Resolves directory for processing from flags. Prints reason and returns false if directory can't be processed
+func prepareCheckPath() bool {
+	if checkPath == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fail("fail get current directory", "error", err)
+		}
+		checkPath = cwd
+	}
+
+	if isNodeDir {
+		checkPath = path.Join(checkPath, "node_modules")
+	}
+
+	if exists, err := isDirectoryExists(checkPath); err != nil {
+		if errors.Is(err, ProvidedFileError) {
+			logger.Error("provided specific file, not a path to directory for clean up", "path", checkPath)
+			return false
+		}
+
+		logger.Error("fail to check path existence", "error", err)
+		return false
+	} else if !exists {
+		logger.Error("provided non exist path", "path", checkPath)
+		return false
+	}
+	return true
+}
+
+func newLogger() shrink.Logger {
+	level := shrink.LevelError
+	if verboseOutput {
+		level = shrink.LevelDebug
+	}
+
+	if logLevel != "" {
+		var err error
+		if level, err = shrink.ParseLevel(logLevel); err != nil {
+			fail("fail parse --log-level value", "value", logLevel, "error", err)
+		}
+	}
+
+	logger, err := shrink.NewLogger(os.Stderr, shrink.LogFormat(logFormat), level)
+	if err != nil {
+		fail("fail parse --log-format value", "value", logFormat, "error", err)
+	}
+	return logger
+}
+
+// Loads config file from --config flag or default config file from current directory
+func loadFileConfig() *shrink.FileConfig {
+	path := configFile
+	if path == "" {
+		if _, err := os.Stat(shrink.DefaultConfigFileName); err != nil {
+			return nil
+		}
+		path = shrink.DefaultConfigFileName
+	}
+
+	cfg, err := shrink.LoadFileConfig(path)
+	if err != nil {
+		fail("fail load config file", "error", err)
+	}
+	return cfg
+}
+
+// Builds shrinker config from common flags and config file. Conditions are taken only from flags of command
+// registered by addConditionFlags, other commands remove matched entries regardless of their size and age
+func shrinkConfig(cmd *cobra.Command) *shrink.Config {
+	cfg := &shrink.Config{
+		CheckPath:     checkPath,
+		VerboseOutput: verboseOutput,
+		Logger:        logger,
+	}
+	if _, hasConditions := cmd.Annotations[conditionsAnnotation]; hasConditions {
+		cfg.Conditions = parseConditions(cmd)
+	}
+
+	platform := presetPlatform()
+	for _, name := range presetNames {
+		preset, err := shrink.PresetByName(name)
+		if err != nil {
+			fail("fail use --preset value", "error", err, "available", strings.Join(shrink.PresetNames(), ", "))
+		}
+		cfg.Rules = append(cfg.Rules, preset.Rules()...)
+		cfg.Matchers = append(cfg.Matchers, preset.AllMatchers(platform)...)
+	}
+
+	if fileConfig != nil {
+		cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleExclude, shrink.SourceConfigFile, fileConfig.Exclude)...)
+		cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleInclude, shrink.SourceConfigFile, fileConfig.Include)...)
+		cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleExtension, shrink.SourceConfigFile, fileConfig.Ext)...)
+		cfg.Rules = append(cfg.Rules, fileConfig.PackagesRules()...)
+		cfg.OverridePackages = fileConfig.OverridePackages()
+	}
+
+	cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleExclude, shrink.SourceFlag, excludeNames)...)
+	cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleInclude, shrink.SourceFlag, includeNames)...)
+	cfg.Rules = append(cfg.Rules, shrink.NewRules(shrink.RuleExtension, shrink.SourceFlag, includeExtensions)...)
+	return cfg
+}
+
+// Returns target platform of presets from --platform flag or config file, empty string for platform of current OS
+func presetPlatform() string {
+	name := targetPlatform
+	if name == "" && fileConfig != nil {
+		name = fileConfig.Platform
+	}
+	if name == "" {
+		return ""
+	}
+
+	platform, err := shrink.ParsePlatform(name)
+	if err != nil {
+		fail("fail use target platform", "error", err)
+	}
+	return platform
+}
+
+func addConditionFlags(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[conditionsAnnotation] = "true"
+
+	cmd.Flags().String("min-size", "", "remove only matched entries with this size or bigger (e.g. 1MB). Directories are checked by total size")
+	cmd.Flags().String("max-size", "", "remove only matched entries with this size or smaller (e.g. 100KB)")
+	cmd.Flags().String("older-than", "", "remove only matched entries which weren't modified during provided period (e.g. 30d, 2w, 12h)")
+	cmd.Flags().String("newer-than", "", "remove only matched entries modified during provided period (e.g. 30d, 2w, 12h)")
+}
+
+// Parses size and age conditions from flags provided to command. Exits if any value is invalid
+func parseConditions(cmd *cobra.Command) shrink.Conditions {
+	conditions := shrink.Conditions{}
+
+	for _, flag := range []string{"min-size", "max-size"} {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(flag)
+		size, err := shrink.ParseByteSize(value)
+		if err != nil {
+			fail("fail parse --"+flag+" value", "error", err)
+		}
+		if flag == "min-size" {
+			conditions.MinSize = int64(size)
+		} else {
+			conditions.MaxSize = int64(size)
+		}
+	}
+
+	for _, flag := range []string{"older-than", "newer-than"} {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(flag)
+		duration, err := shrink.ParseDuration(value)
+		if err != nil {
+			fail("fail parse --"+flag+" value", "error", err)
+		}
+		if flag == "older-than" {
+			conditions.OlderThan = duration
+		} else {
+			conditions.NewerThan = duration
+		}
+	}
+	return conditions
+}
+
+// Checks is any include or extension rule provided by user
+func hasRemoveRules(cfg *shrink.Config) bool {
+	for _, rule := range cfg.Rules {
+		if rule.Kind != shrink.RuleExclude {
+			return true
+		}
+	}
+	return len(cfg.IncludeNames) != 0 || len(cfg.RemoveFileExt) != 0
+}
+
+func newShrinker(cfg *shrink.Config) *shrink.Shrinker {
+	shrinker, err := shrink.NewShrinker(cfg)
+	if err != nil {
+		if errors.Is(err, shrink.NotExistError) {
+			fail("path doesn't exist", "path", cfg.CheckPath)
+		}
+
+		fail("something has broken", "error", err)
+	}
+	return shrinker
+}
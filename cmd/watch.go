@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+
+	"github.com/icecream78/node_shrinker/shrink"
+	"github.com/icecream78/node_shrinker/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchDebounce time.Duration
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "watch node_modules and shrink packages after every install",
+	Long: `Watches node_modules directories and lockfiles of the project for changes made by npm, yarn or pnpm.
+When there are no new changes during --debounce period, only added or updated packages are shrunk
+with the same rules as usual run. Lockfiles are compared with their previous state to find
+updated packages. Stops on interrupt.
+
+Watching is supported only on linux.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prepareCheckPath() {
+			return
+		}
+
+		absCheckPath, err := filepath.Abs(checkPath)
+		if err != nil {
+			fail("fail resolve path", "error", err)
+		}
+		checkPath = absCheckPath
+
+		shrinker := newShrinker(shrinkConfig(cmd))
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			select {
+			case <-signals:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		watcher, err := watch.New(shrink.ProjectPath(checkPath), shrink.IsWatchedDir)
+		if err != nil {
+			if errors.Is(err, watch.NotSupportedError) {
+				fail("watch mode isn't supported on this platform")
+			}
+
+			fail("fail watch directory", "error", err)
+		}
+		defer watcher.Close()
+
+		go func() {
+			for err := range watcher.Errors() {
+				logger.Warn("watch error", "error", err)
+			}
+		}()
+
+		printf("Watching %s, press Ctrl+C to stop\n", checkPath)
+
+		batches := watch.Debounce(ctx, watcher.Events(), watchDebounce)
+		shrinker.Watch(ctx, batches, func(result *shrink.WatchResult) {
+			printf("%s shrunk %d packages, released space: %v, files count: %d\n",
+				time.Now().Format("15:04:05"),
+				len(result.Packages),
+				color.Cyan(humanize.Bytes(uint64(result.Stats.Size()))),
+				color.Cyan(result.Stats.FilesCount()),
+			)
+		})
+	},
+}
+
+func init() {
+	addConditionFlags(watchCmd)
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 3*time.Second, "period without file system changes after which install is treated as finished")
+
+	rootCmd.AddCommand(watchCmd)
+}
@@ -1,5 +1,10 @@
 package fs
 
+import (
+	"os"
+	"time"
+)
+
 type SizeFormat int64
 
 const (
@@ -13,6 +18,11 @@ type FileStat struct {
 	fullpath   string
 	size       int64
 	filesCount int64
+	modTime    time.Time
+	mode       os.FileMode
+	device     uint64
+	inode      uint64
+	changeTime time.Time
 }
 
 func (fs *FileStat) Size() int64 {
@@ -35,3 +45,29 @@ func (fs *FileStat) GetHumanSizeFormat(format SizeFormat) int64 {
 func (fs *FileStat) FilesCount() int64 {
 	return fs.filesCount
 }
+
+// Returns last modification time. Recursive stats don't have it and return zero time
+func (fs *FileStat) ModTime() time.Time {
+	return fs.modTime
+}
+
+// Returns file mode. Recursive stats don't have it and return zero mode
+func (fs *FileStat) Mode() os.FileMode {
+	return fs.mode
+}
+
+// Returns inode of file, zero if platform doesn't have inodes. Recursive stats don't have it
+func (fs *FileStat) Inode() uint64 {
+	return fs.inode
+}
+
+// Returns device containing file, inode is unique only inside of it. Zero if platform doesn't have inodes
+func (fs *FileStat) Device() uint64 {
+	return fs.device
+}
+
+// Returns time of last status change: creation, writing, linking or changing of permissions.
+// It's modification time on platforms without status change time. Recursive stats don't have it
+func (fs *FileStat) ChangeTime() time.Time {
+	return fs.changeTime
+}
@@ -1,6 +1,9 @@
 package fs
 
 import (
+	"errors"
+	"io"
+	"io/ioutil"
 	"os"
 
 	. "github.com/icecream78/node_shrinker/walker"
@@ -9,10 +12,24 @@ import (
 type FS interface {
 	Getwd() (string, error)
 	Stat(filepath string, recursive bool) (*FileStat, error)
+	Lstat(filepath string) (*FileStat, error) // like non recursive Stat, but doesn't follow symlink
 	RemoveAll(filepath string) error
 	Remove(filepath string) error
+	ReadFile(filepath string) ([]byte, error)
+	WriteFile(filepath string, data []byte) error
+	Open(filepath string) (io.ReadCloser, error)
+	ReadDir(filepath string) ([]os.FileInfo, error)
+	SameFile(filepath1, filepath2 string) bool
+	SameData(filepath1, filepath2 string) bool // the same file or copy-on-write clones sharing all extents
+	Hardlink(src, dst string) error
+	Reflink(src, dst string) error
 }
 
+var ReflinkNotSupportedError = errors.New("reflinks aren't supported")
+
+// suffix of temporary file, which replaces destination file after successful linking
+const linkTmpSuffix string = ".node_shrinker.tmp"
+
 func NewFS() *fsClass {
 	return &fsClass{}
 }
@@ -29,13 +46,30 @@ func (fs *fsClass) Stat(filepath string, recursive bool) (*FileStat, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newOSFileStat(filepath, stat), nil
+}
+
+func (fs *fsClass) Lstat(filepath string) (*FileStat, error) {
+	stat, err := os.Lstat(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return newOSFileStat(filepath, stat), nil
+}
 
+func newOSFileStat(filepath string, stat os.FileInfo) *FileStat {
+	device, inode, changeTime := fileIdentity(stat)
 	return &FileStat{
 		filename:   stat.Name(),
 		fullpath:   filepath,
 		size:       stat.Size(),
 		filesCount: 1,
-	}, nil
+		modTime:    stat.ModTime(),
+		mode:       stat.Mode(),
+		device:     device,
+		inode:      inode,
+		changeTime: changeTime,
+	}
 }
 
 func (fs *fsClass) getRecursiveStat(filepath string) (*FileStat, error) {
@@ -45,7 +79,11 @@ func (fs *fsClass) getRecursiveStat(filepath string) (*FileStat, error) {
 			return nil // skip for non recursive work
 		}
 
-		st, stErr := fs.Stat(path, de.IsDir())
+		if de.IsDir() {
+			return nil // walker goes inside directory itself, so only files are counted
+		}
+
+		st, stErr := fs.Stat(path, false)
 		if stErr != nil {
 			// cannnot get stat from file, so we cannot remove it and not count this file in result stats
 			return nil
@@ -71,3 +109,64 @@ func (fs *fsClass) Remove(filepath string) error {
 func (fs *fsClass) Getwd() (string, error) {
 	return os.Getwd()
 }
+
+func (fs *fsClass) ReadFile(filepath string) ([]byte, error) {
+	return ioutil.ReadFile(filepath)
+}
+
+// Returns entries of directory sorted by name
+func (fs *fsClass) ReadDir(filepath string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(filepath)
+}
+
+func (fs *fsClass) WriteFile(filepath string, data []byte) error {
+	return ioutil.WriteFile(filepath, data, 0644)
+}
+
+func (fs *fsClass) Open(filepath string) (io.ReadCloser, error) {
+	return os.Open(filepath)
+}
+
+func (fs *fsClass) SameFile(filepath1, filepath2 string) bool {
+	stat1, err := os.Lstat(filepath1)
+	if err != nil {
+		return false
+	}
+
+	stat2, err := os.Lstat(filepath2)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(stat1, stat2)
+}
+
+func (fs *fsClass) SameData(filepath1, filepath2 string) bool {
+	return fs.SameFile(filepath1, filepath2) || sameExtents(filepath1, filepath2)
+}
+
+// Replaces dst with hardlink to src. Replacement is atomic, so dst is never lost on errors
+func (fs *fsClass) Hardlink(src, dst string) error {
+	tmp := dst + linkTmpSuffix
+	if err := os.Link(src, tmp); err != nil {
+		return err
+	}
+	return replaceWithTmp(tmp, dst)
+}
+
+// Replaces dst with copy-on-write clone of src. Returns ReflinkNotSupportedError if file system can't do it
+func (fs *fsClass) Reflink(src, dst string) error {
+	tmp := dst + linkTmpSuffix
+	if err := reflink(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return replaceWithTmp(tmp, dst)
+}
+
+func replaceWithTmp(tmp, dst string) error {
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,676 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+// size of directory entry itself, the same as on most Linux file systems
+const memDirSize int64 = 4096
+
+// max count of symlinks followed during resolving path, the same limit as Linux has
+const memMaxSymlinks int = 40
+
+// modification time of entries without explicit one, so trees are deterministic
+var MemDefaultModTime time.Time = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// MemEntry describes single entry of in-memory tree
+type MemEntry struct {
+	Data    string
+	IsDir   bool
+	Symlink string      // target of symlink, relative to directory of symlink or absolute
+	Perm    os.FileMode // 0644 for files and 0755 for directories by default
+	ModTime time.Time   // MemDefaultModTime by default
+	Denied  bool        // content of entry can't be read and nested entries can't be changed, like for entry without permissions
+}
+
+// MemTree maps pathes relative to root of in-memory file system to entries.
+// Parent directories are created automatically
+type MemTree map[string]MemEntry
+
+func MemFile(data string) MemEntry {
+	return MemEntry{Data: data}
+}
+
+func MemDir() MemEntry {
+	return MemEntry{IsDir: true}
+}
+
+func MemSymlink(target string) MemEntry {
+	return MemEntry{Symlink: target}
+}
+
+// content of regular file, shared by hardlinks
+type memFile struct {
+	data []byte
+}
+
+type memNode struct {
+	mode       os.FileMode
+	file       *memFile // nil for directories and symlinks
+	target     string   // target of symlink
+	modTime    time.Time
+	changeTime time.Time
+	inode      uint64 // shared by hardlinks
+	denied     bool
+}
+
+// inodes are unique across all in-memory file systems, so tree created again gets new ones
+var memLastInode uint64
+
+func newMemInode() uint64 {
+	return atomic.AddUint64(&memLastInode, 1)
+}
+
+func (n *memNode) size() int64 {
+	switch {
+	case n.mode.IsDir():
+		return memDirSize
+	case n.file != nil:
+		return int64(len(n.file.data))
+	}
+	return int64(len(n.target))
+}
+
+// MemFS is in-memory implementation of FS and walker.Walker, which behaves like OS:
+// directories are walked in sorted order, symlinks are not followed by walker, but followed by Stat,
+// denied entries return permission errors. It's safe for concurrent use
+type MemFS struct {
+	mu    sync.RWMutex
+	root  string
+	nodes map[string]*memNode // by clean absolute path
+}
+
+// Creates in-memory file system with provided tree under root directory
+func NewMemFS(root string, tree MemTree) *MemFS {
+	root = filepath.Clean(root)
+	fs := &MemFS{root: root, nodes: make(map[string]*memNode)}
+	fs.mkdirAll(root, MemDefaultModTime)
+
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := tree[name]
+		path := filepath.Join(root, filepath.FromSlash(name))
+		fs.mkdirAll(filepath.Dir(path), MemDefaultModTime)
+
+		modTime := entry.ModTime
+		if modTime.IsZero() {
+			modTime = MemDefaultModTime
+		}
+
+		node := &memNode{modTime: modTime, changeTime: modTime, inode: newMemInode(), denied: entry.Denied}
+		switch {
+		case entry.IsDir:
+			node.mode = os.ModeDir | permOrDefault(entry.Perm, 0755)
+		case entry.Symlink != "":
+			node.mode = os.ModeSymlink | 0777
+			node.target = entry.Symlink
+		default:
+			node.mode = permOrDefault(entry.Perm, 0644)
+			node.file = &memFile{data: []byte(entry.Data)}
+		}
+
+		if existing, exists := fs.nodes[path]; exists && existing.mode.IsDir() && node.mode.IsDir() {
+			existing.mode, existing.modTime, existing.denied = node.mode, node.modTime, node.denied
+			continue
+		}
+		fs.nodes[path] = node
+	}
+	return fs
+}
+
+func permOrDefault(perm, defaultPerm os.FileMode) os.FileMode {
+	if perm == 0 {
+		return defaultPerm
+	}
+	return perm.Perm()
+}
+
+func (fs *MemFS) mkdirAll(path string, modTime time.Time) {
+	for current := path; ; current = filepath.Dir(current) {
+		if _, exists := fs.nodes[current]; !exists {
+			fs.nodes[current] = &memNode{mode: os.ModeDir | 0755, modTime: modTime, changeTime: modTime, inode: newMemInode()}
+		}
+		if current == filepath.Dir(current) {
+			return
+		}
+	}
+}
+
+func pathError(op, path string, err error) error {
+	return &os.PathError{Op: op, Path: path, Err: err}
+}
+
+// Resolves symlinks in path. The last element is resolved only if follow is true.
+// Every parent directory must be readable, like search permission on OS
+func (fs *MemFS) resolve(op, path string, follow bool) (string, *memNode, error) {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(fs.root, path)
+	}
+
+	for hops := 0; hops <= memMaxSymlinks; hops++ {
+		resolved, node, target, err := fs.resolveOnce(op, path, follow)
+		if err != nil || target == "" {
+			return resolved, node, err
+		}
+		path = target
+	}
+	return "", nil, pathError(op, path, syscall.ELOOP)
+}
+
+// Walks path from root and returns node or new path to resolve if any symlink is met
+func (fs *MemFS) resolveOnce(op, path string, follow bool) (resolved string, node *memNode, target string, err error) {
+	parts := strings.Split(strings.TrimPrefix(path, string(filepath.Separator)), string(filepath.Separator))
+	current := string(filepath.Separator)
+	node = fs.nodes[current]
+
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		if !node.mode.IsDir() {
+			return "", nil, "", pathError(op, path, syscall.ENOTDIR)
+		}
+		if node.denied {
+			return "", nil, "", pathError(op, path, os.ErrPermission)
+		}
+
+		current = filepath.Join(current, part)
+		next, exists := fs.nodes[current]
+		if !exists {
+			return "", nil, "", pathError(op, path, os.ErrNotExist)
+		}
+
+		isLast := i == len(parts)-1
+		if next.mode&os.ModeSymlink != 0 && (!isLast || follow) {
+			linkTarget := next.target
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Join(filepath.Dir(current), linkTarget)
+			}
+			return "", nil, filepath.Join(append([]string{linkTarget}, parts[i+1:]...)...), nil
+		}
+		node = next
+	}
+	return current, node, "", nil
+}
+
+func (fs *MemFS) fileInfo(path string, node *memNode) *FileInfo {
+	return NewFileInfoWithStat(filepath.Base(path), node.mode, node.size(), node.modTime)
+}
+
+func (fs *MemFS) Getwd() (string, error) {
+	return fs.root, nil
+}
+
+func (fs *MemFS) Stat(path string, recursive bool) (*FileStat, error) {
+	if recursive {
+		return fs.recursiveStat(path)
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	_, node, err := fs.resolve("stat", path, true)
+	if err != nil {
+		return nil, err
+	}
+	return fs.fileStat(path, node), nil
+}
+
+func (fs *MemFS) Lstat(path string) (*FileStat, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	_, node, err := fs.resolve("lstat", path, false)
+	if err != nil {
+		return nil, err
+	}
+	return fs.fileStat(path, node), nil
+}
+
+func (fs *MemFS) fileStat(path string, node *memNode) *FileStat {
+	return &FileStat{
+		filename:   filepath.Base(path),
+		fullpath:   path,
+		size:       node.size(),
+		filesCount: 1,
+		modTime:    node.modTime,
+		mode:       node.mode,
+		inode:      node.inode,
+		changeTime: node.changeTime,
+	}
+}
+
+// Creates directory with missing parents like os.MkdirAll, e.g. to install package again in tests
+func (fs *MemFS) MkdirAll(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	path = filepath.Clean(path)
+	for current := path; ; current = filepath.Dir(current) {
+		if node, exists := fs.nodes[current]; exists && !node.mode.IsDir() {
+			return pathError("mkdir", path, syscall.ENOTDIR)
+		}
+		if current == filepath.Dir(current) {
+			break
+		}
+	}
+
+	fs.mkdirAll(path, time.Now())
+	return nil
+}
+
+// Counts only files like OS implementation does. Symlinks are counted with size of their targets
+func (fs *MemFS) recursiveStat(path string) (*FileStat, error) {
+	stats := FileStat{filename: path, fullpath: path}
+	err := fs.Walk(path, func(osPathname string, de FileInfoI) error {
+		if osPathname == path || de.IsDir() {
+			return nil
+		}
+
+		stat, err := fs.Stat(osPathname, false)
+		if err != nil {
+			return nil
+		}
+		stats.size += stat.Size()
+		stats.filesCount++
+		return nil
+	}, func(string, error) ErrorAction {
+		return SkipNode
+	})
+	return &stats, err
+}
+
+// Lists directory content sorted by name like ioutil.ReadDir does
+func (fs *MemFS) ReadDir(path string) ([]os.FileInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	resolved, node, err := fs.resolve("open", path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := fs.children(resolved, node)
+	if err != nil {
+		return nil, pathError("open", path, err)
+	}
+
+	infos := make([]os.FileInfo, 0, len(names))
+	for _, name := range names {
+		child := filepath.Join(resolved, name)
+		node := fs.nodes[child]
+		infos = append(infos, &memFileInfo{name: name, mode: node.mode, size: node.size(), modTime: node.modTime})
+	}
+	return infos, nil
+}
+
+// Returns sorted names of directory entries
+func (fs *MemFS) children(dir string, node *memNode) ([]string, error) {
+	if !node.mode.IsDir() {
+		return nil, syscall.ENOTDIR
+	}
+	if node.denied {
+		return nil, os.ErrPermission
+	}
+
+	prefix := dir + string(filepath.Separator)
+	if dir == string(filepath.Separator) {
+		prefix = dir
+	}
+
+	names := make([]string, 0)
+	for path := range fs.nodes {
+		if path != dir && strings.HasPrefix(path, prefix) && !strings.Contains(path[len(prefix):], string(filepath.Separator)) {
+			names = append(names, path[len(prefix):])
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+type memFileInfo struct {
+	name    string
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return fi.size }
+func (fi *memFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *memFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *memFileInfo) Sys() interface{}   { return nil }
+
+// Walks tree in sorted order with the same error handling as walker based on godirwalk.
+// Lock isn't held during callbacks, so they are free to change tree. Like on OS, entries of
+// directory are read before they are passed to callback
+func (fs *MemFS) Walk(path string, callback WalkFunc, errCallback WalkErrFunc) error {
+	path = filepath.Clean(path)
+
+	fs.mu.RLock()
+	resolved, node, err := fs.resolve("lstat", path, true)
+	var info *FileInfo
+	if err == nil {
+		info = fs.fileInfo(path, node)
+	}
+	fs.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+	return fs.walkNode(path, resolved, info, callback, errCallback)
+}
+
+type memChild struct {
+	name string
+	info *FileInfo
+}
+
+func (fs *MemFS) readChildren(dir string) ([]memChild, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	node, exists := fs.nodes[dir]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+
+	names, err := fs.children(dir, node)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]memChild, 0, len(names))
+	for _, name := range names {
+		child := filepath.Join(dir, name)
+		children = append(children, memChild{name: name, info: fs.fileInfo(child, fs.nodes[child])})
+	}
+	return children, nil
+}
+
+func (fs *MemFS) walkNode(path, resolved string, info *FileInfo, callback WalkFunc, errCallback WalkErrFunc) error {
+	if err := callback(path, info); err != nil && err != NotProcessError {
+		if errCallback(path, err) == Halt {
+			return err
+		}
+		return nil
+	}
+
+	if !info.IsDir() {
+		return nil
+	}
+
+	children, err := fs.readChildren(resolved)
+	if err == os.ErrNotExist {
+		return nil // removed by callback
+	}
+	if err != nil {
+		err = pathError("open", path, err)
+		if errCallback(path, err) == Halt {
+			return err
+		}
+		return nil
+	}
+
+	for _, child := range children {
+		if err := fs.walkNode(filepath.Join(path, child.name), filepath.Join(resolved, child.name), child.info, callback, errCallback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks that entries can be added or removed in directory of path
+func (fs *MemFS) checkParent(op, path string) (string, error) {
+	dir, parent, err := fs.resolve(op, filepath.Dir(path), true)
+	if err != nil {
+		return "", err
+	}
+	if !parent.mode.IsDir() {
+		return "", pathError(op, path, syscall.ENOTDIR)
+	}
+	if parent.denied {
+		return "", pathError(op, path, os.ErrPermission)
+	}
+	return filepath.Join(dir, filepath.Base(path)), nil
+}
+
+func (fs *MemFS) RemoveAll(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	resolved, node, err := fs.resolve("unlinkat", path, false)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if _, err := fs.checkParent("unlinkat", resolved); err != nil {
+		return err
+	}
+
+	if fs.removeTree(resolved, node) {
+		return nil
+	}
+	return pathError("unlinkat", path, os.ErrPermission)
+}
+
+// Removes everything which can be removed. Returns false if some entries are left in denied directories
+func (fs *MemFS) removeTree(path string, node *memNode) bool {
+	if node.mode.IsDir() {
+		if node.denied {
+			return false
+		}
+
+		names, _ := fs.children(path, node)
+		removedAll := true
+		for _, name := range names {
+			child := filepath.Join(path, name)
+			if !fs.removeTree(child, fs.nodes[child]) {
+				removedAll = false
+			}
+		}
+		if !removedAll {
+			return false
+		}
+	}
+
+	delete(fs.nodes, path)
+	return true
+}
+
+func (fs *MemFS) Remove(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	resolved, node, err := fs.resolve("remove", path, false)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.checkParent("remove", resolved); err != nil {
+		return err
+	}
+
+	if node.mode.IsDir() {
+		names, err := fs.children(resolved, node)
+		if err != nil {
+			return pathError("remove", path, err)
+		}
+		if len(names) != 0 {
+			return pathError("remove", path, syscall.ENOTEMPTY)
+		}
+	}
+
+	delete(fs.nodes, resolved)
+	return nil
+}
+
+func (fs *MemFS) readFile(op, path string) ([]byte, error) {
+	_, node, err := fs.resolve(op, path, true)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, pathError("read", path, syscall.EISDIR)
+	}
+	if node.denied {
+		return nil, pathError(op, path, os.ErrPermission)
+	}
+	return append([]byte(nil), node.file.data...), nil
+}
+
+func (fs *MemFS) ReadFile(path string) ([]byte, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	return fs.readFile("open", path)
+}
+
+func (fs *MemFS) WriteFile(path string, data []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	resolved, node, err := fs.resolve("open", path, true)
+	if err == nil {
+		if node.mode.IsDir() {
+			return pathError("open", path, syscall.EISDIR)
+		}
+		if node.denied {
+			return pathError("open", path, os.ErrPermission)
+		}
+
+		// content is shared with hardlinks like inode on OS
+		node.file.data = append([]byte(nil), data...)
+		node.modTime = time.Now()
+		node.changeTime = node.modTime
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	if resolved, err = fs.checkParent("open", path); err != nil {
+		return err
+	}
+	now := time.Now()
+	fs.nodes[resolved] = &memNode{mode: 0644, file: &memFile{data: append([]byte(nil), data...)}, modTime: now, changeTime: now, inode: newMemInode()}
+	return nil
+}
+
+func (fs *MemFS) Open(path string) (io.ReadCloser, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	data, err := fs.readFile("open", path)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Returns target of symlink as it was created
+func (fs *MemFS) Readlink(path string) (string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	_, node, err := fs.resolve("readlink", path, false)
+	if err != nil {
+		return "", err
+	}
+	if node.mode&os.ModeSymlink == 0 {
+		return "", pathError("readlink", path, syscall.EINVAL)
+	}
+	return node.target, nil
+}
+
+func (fs *MemFS) SameFile(path1, path2 string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	_, node1, err := fs.resolve("lstat", path1, false)
+	if err != nil {
+		return false
+	}
+	_, node2, err := fs.resolve("lstat", path2, false)
+	if err != nil {
+		return false
+	}
+
+	if node1.file != nil {
+		return node1.file == node2.file
+	}
+	return node1 == node2
+}
+
+// In-memory file system has no copy-on-write clones, so only hardlinks share data
+func (fs *MemFS) SameData(path1, path2 string) bool {
+	return fs.SameFile(path1, path2)
+}
+
+// Replaces dst with hardlink to src, both entries share content after it
+func (fs *MemFS) Hardlink(src, dst string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	_, srcNode, err := fs.resolve("link", src, false)
+	if err != nil {
+		return err
+	}
+	if srcNode.file == nil {
+		return pathError("link", src, os.ErrPermission) // directories can't be hardlinked
+	}
+
+	resolved, err := fs.checkParent("link", dst)
+	if err != nil {
+		return err
+	}
+	if dstNode, exists := fs.nodes[resolved]; exists && dstNode.mode.IsDir() {
+		return pathError("rename", dst, syscall.EISDIR)
+	}
+
+	srcNode.changeTime = time.Now() // count of links is changed
+	fs.nodes[resolved] = &memNode{mode: srcNode.mode, file: srcNode.file, modTime: srcNode.modTime, changeTime: srcNode.changeTime, inode: srcNode.inode}
+	return nil
+}
+
+// In-memory file system doesn't support copy-on-write clones like tmpfs
+func (fs *MemFS) Reflink(src, dst string) error {
+	return ReflinkNotSupportedError
+}
+
+// Returns paths of all entries sorted by name, useful for comparing trees in tests
+func (fs *MemFS) Paths() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	paths := make([]string, 0, len(fs.nodes))
+	for path := range fs.nodes {
+		if path == fs.root || !strings.HasPrefix(path, fs.root+string(filepath.Separator)) {
+			continue
+		}
+		relPath, _ := filepath.Rel(fs.root, path)
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,164 @@
+package fs
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/icecream78/node_shrinker/walker"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemFS() *MemFS {
+	return NewMemFS("/root", MemTree{
+		"a/index.js":     MemFile("12345"),
+		"a/lib/util.js":  MemFile("123"),
+		"a/link":         MemSymlink("lib/util.js"),
+		"a/dir-link":     MemSymlink("lib"),
+		"a/broken":       MemSymlink("missing"),
+		"locked":         {IsDir: true, Denied: true},
+		"locked/file.js": MemFile("secret"),
+		"empty":          MemDir(),
+	})
+}
+
+func TestMemFSStatFunc(t *testing.T) {
+	memFS := newTestMemFS()
+
+	testCases := []struct {
+		alias     string
+		path      string
+		recursive bool
+		wantSize  int64
+		wantFiles int64
+		wantErr   func(error) bool
+	}{
+		{"Test file", "/root/a/index.js", false, 5, 1, nil},
+		{"Test relative path", "a/index.js", false, 5, 1, nil},
+		{"Test directory", "/root/a/lib", false, memDirSize, 1, nil},
+		{"Test symlink is followed", "/root/a/link", false, 3, 1, nil},
+		{"Test path through symlink", "/root/a/dir-link/util.js", false, 3, 1, nil},
+		{"Test recursive directory, symlinks are counted with size of targets", "/root/a", true, 5 + 3 + 3 + memDirSize, 4, nil},
+		{"Test missing file", "/root/missing", false, 0, 0, os.IsNotExist},
+		{"Test broken symlink", "/root/a/broken", false, 0, 0, os.IsNotExist},
+		{"Test file in denied directory", "/root/locked/file.js", false, 0, 0, os.IsPermission},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			stat, err := memFS.Stat(tc.path, tc.recursive)
+			if tc.wantErr != nil {
+				assert.True(t, tc.wantErr(err), err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantSize, stat.Size())
+			assert.Equal(t, tc.wantFiles, stat.FilesCount())
+			if !tc.recursive {
+				assert.Equal(t, MemDefaultModTime, stat.ModTime())
+			}
+		})
+	}
+}
+
+func TestMemFSLstatFunc(t *testing.T) {
+	memFS := newTestMemFS()
+
+	stat, err := memFS.Lstat("/root/a/link")
+	assert.Nil(t, err)
+	assert.True(t, stat.Mode()&os.ModeSymlink != 0)
+
+	stat, err = memFS.Lstat("/root/a/broken")
+	assert.Nil(t, err)
+	assert.True(t, stat.Mode()&os.ModeSymlink != 0)
+
+	// symlinks of parents are followed
+	stat, err = memFS.Lstat("/root/a/dir-link/util.js")
+	assert.Nil(t, err)
+	assert.True(t, stat.Mode().IsRegular())
+
+	_, err = memFS.Lstat("/root/missing")
+	assert.True(t, os.IsNotExist(err), err)
+}
+
+func TestMemFSWalkFunc(t *testing.T) {
+	memFS := newTestMemFS()
+
+	var walked []string
+	var failed []string
+	err := memFS.Walk("/root", func(osPathname string, de FileInfoI) error {
+		walked = append(walked, osPathname)
+		if de.Name() == "lib" {
+			assert.True(t, de.IsDir())
+			return NotProcessError
+		}
+		if de.Name() == "link" {
+			assert.False(t, de.IsRegular())
+			assert.Equal(t, os.ModeSymlink, de.Mode()&os.ModeSymlink)
+		}
+		if de.Name() == "empty" {
+			return SkipDirError
+		}
+		return nil
+	}, func(osPathname string, err error) ErrorAction {
+		failed = append(failed, osPathname)
+		return SkipNode
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"/root",
+		"/root/a",
+		"/root/a/broken",
+		"/root/a/dir-link",
+		"/root/a/index.js",
+		"/root/a/lib",
+		"/root/a/lib/util.js",
+		"/root/a/link",
+		"/root/empty",
+		"/root/locked",
+	}, walked)
+	assert.Equal(t, []string{"/root/empty", "/root/locked"}, failed)
+}
+
+func TestMemFSRemoveFunc(t *testing.T) {
+	memFS := newTestMemFS()
+
+	assert.NotNil(t, memFS.Remove("/root/a/lib"))
+	assert.True(t, os.IsPermission(memFS.RemoveAll("/root/locked")))
+	assert.Nil(t, memFS.RemoveAll("/root/missing"))
+
+	// symlink is removed, not its target
+	assert.Nil(t, memFS.RemoveAll("/root/a/dir-link"))
+	assert.Nil(t, memFS.Remove("/root/empty"))
+	assert.Nil(t, memFS.RemoveAll("/root/a/lib"))
+
+	assert.Equal(t, []string{
+		"a",
+		"a/broken",
+		"a/index.js",
+		"a/link",
+		"locked",
+		"locked/file.js",
+	}, memFS.Paths())
+}
+
+func TestMemFSLinkFunc(t *testing.T) {
+	memFS := NewMemFS("/root", MemTree{
+		"a.js": MemFile("same"),
+		"b.js": MemFile("same"),
+	})
+
+	assert.False(t, memFS.SameFile("/root/a.js", "/root/b.js"))
+	assert.Equal(t, ReflinkNotSupportedError, memFS.Reflink("/root/a.js", "/root/b.js"))
+	assert.Nil(t, memFS.Hardlink("/root/a.js", "/root/b.js"))
+	assert.True(t, memFS.SameFile("/root/a.js", "/root/b.js"))
+
+	// hardlinks share content
+	assert.Nil(t, memFS.WriteFile("/root/a.js", []byte("changed")))
+	data, err := memFS.ReadFile("/root/b.js")
+	assert.Nil(t, err)
+	assert.Equal(t, "changed", string(data))
+
+	assert.NotNil(t, memFS.WriteFile("/root/missing/c.js", nil))
+}
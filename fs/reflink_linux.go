@@ -0,0 +1,120 @@
+//go:build linux
+// +build linux
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// FICLONE ioctl request from linux/fs.h
+const ficlone uintptr = 0x40049409
+
+func reflink(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	stat, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dstFile.Fd(), ficlone, srcFile.Fd())
+	switch errno {
+	case 0:
+		return nil
+	case syscall.EOPNOTSUPP, syscall.ENOTTY, syscall.EINVAL, syscall.EXDEV, syscall.ENOSYS:
+		return ReflinkNotSupportedError
+	}
+	return errno
+}
+
+// FS_IOC_FIEMAP ioctl request and flags from linux/fiemap.h
+const (
+	fsIocFiemap uintptr = 0xC020660B
+
+	fiemapFlagSync            uint32 = 0x1
+	fiemapExtentLast          uint32 = 0x1
+	fiemapExtentUnknown       uint32 = 0x2
+	fiemapExtentDelalloc      uint32 = 0x4
+	fiemapExtentNotAligned    uint32 = 0x100
+	fiemapExtentDataInline    uint32 = 0x200
+	fiemapExtentUnwritten     uint32 = 0x800
+	fiemapExtentMaxCount             = 64
+	fiemapExtentNotComparable        = fiemapExtentUnknown | fiemapExtentDelalloc | fiemapExtentNotAligned | fiemapExtentDataInline | fiemapExtentUnwritten
+)
+
+type fiemapExtent struct {
+	Logical    uint64
+	Physical   uint64
+	Length     uint64
+	reserved64 [2]uint64
+	Flags      uint32
+	reserved   [3]uint32
+}
+
+type fiemap struct {
+	Start         uint64
+	Length        uint64
+	Flags         uint32
+	MappedExtents uint32
+	ExtentCount   uint32
+	reserved      uint32
+	Extents       [fiemapExtentMaxCount]fiemapExtent
+}
+
+// Returns physical extents of file. False if they can't be compared: file system doesn't report them,
+// data isn't placed on disk yet or file has too many extents
+func fileExtents(path string) ([]fiemapExtent, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	request := fiemap{Length: ^uint64(0), Flags: fiemapFlagSync, ExtentCount: fiemapExtentMaxCount}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIocFiemap, uintptr(unsafe.Pointer(&request))); errno != 0 {
+		return nil, false
+	}
+
+	extents := request.Extents[:request.MappedExtents]
+	if len(extents) == 0 || extents[len(extents)-1].Flags&fiemapExtentLast == 0 {
+		return nil, false
+	}
+	for _, extent := range extents {
+		if extent.Flags&fiemapExtentNotComparable != 0 {
+			return nil, false
+		}
+	}
+	return extents, true
+}
+
+// Checks are files copy-on-write clones, which share all physical extents
+func sameExtents(path1, path2 string) bool {
+	extents1, ok := fileExtents(path1)
+	if !ok {
+		return false
+	}
+	extents2, ok := fileExtents(path2)
+	if !ok || len(extents1) != len(extents2) {
+		return false
+	}
+
+	for i := range extents1 {
+		if extents1[i].Logical != extents2[i].Logical || extents1[i].Physical != extents2[i].Physical || extents1[i].Length != extents2[i].Length {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+func reflink(src, dst string) error {
+	return ReflinkNotSupportedError
+}
+
+// Extents of files aren't available, so clones can't be told apart from copies
+func sameExtents(path1, path2 string) bool {
+	return false
+}
@@ -0,0 +1,20 @@
+//go:build linux
+// +build linux
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// Returns device, inode and status change time of file. Status change time can't be set by utimes,
+// so it changes whenever file is created again, even if modification time is restored
+func fileIdentity(info os.FileInfo) (device, inode uint64, changeTime time.Time) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, info.ModTime()
+	}
+	return uint64(stat.Dev), uint64(stat.Ino), time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))
+}
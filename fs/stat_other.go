@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// Inodes aren't available, so only modification time identifies file
+func fileIdentity(info os.FileInfo) (device, inode uint64, changeTime time.Time) {
+	return 0, 0, info.ModTime()
+}
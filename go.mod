@@ -8,4 +8,5 @@ require (
 	github.com/logrusorgru/aurora v2.0.3+incompatible
 	github.com/spf13/cobra v1.0.0
 	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
 )
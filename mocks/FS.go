@@ -4,7 +4,11 @@ package mocks
 
 import (
 	fs "github.com/icecream78/node_shrinker/fs"
+	io "io"
+
 	mock "github.com/stretchr/testify/mock"
+
+	os "os"
 )
 
 // FS is an autogenerated mock type for the FS type
@@ -33,6 +37,126 @@ func (_m *FS) Getwd() (string, error) {
 	return r0, r1
 }
 
+// Hardlink provides a mock function with given fields: src, dst
+func (_m *FS) Hardlink(src string, dst string) error {
+	ret := _m.Called(src, dst)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(src, dst)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Lstat provides a mock function with given fields: filepath
+func (_m *FS) Lstat(filepath string) (*fs.FileStat, error) {
+	ret := _m.Called(filepath)
+
+	var r0 *fs.FileStat
+	if rf, ok := ret.Get(0).(func(string) *fs.FileStat); ok {
+		r0 = rf(filepath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fs.FileStat)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(filepath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Open provides a mock function with given fields: filepath
+func (_m *FS) Open(filepath string) (io.ReadCloser, error) {
+	ret := _m.Called(filepath)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(string) io.ReadCloser); ok {
+		r0 = rf(filepath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(filepath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadDir provides a mock function with given fields: filepath
+func (_m *FS) ReadDir(filepath string) ([]os.FileInfo, error) {
+	ret := _m.Called(filepath)
+
+	var r0 []os.FileInfo
+	if rf, ok := ret.Get(0).(func(string) []os.FileInfo); ok {
+		r0 = rf(filepath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]os.FileInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(filepath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadFile provides a mock function with given fields: filepath
+func (_m *FS) ReadFile(filepath string) ([]byte, error) {
+	ret := _m.Called(filepath)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(filepath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(filepath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reflink provides a mock function with given fields: src, dst
+func (_m *FS) Reflink(src string, dst string) error {
+	ret := _m.Called(src, dst)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(src, dst)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Remove provides a mock function with given fields: filepath
 func (_m *FS) Remove(filepath string) error {
 	ret := _m.Called(filepath)
@@ -61,6 +185,34 @@ func (_m *FS) RemoveAll(filepath string) error {
 	return r0
 }
 
+// SameData provides a mock function with given fields: filepath1, filepath2
+func (_m *FS) SameData(filepath1 string, filepath2 string) bool {
+	ret := _m.Called(filepath1, filepath2)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(filepath1, filepath2)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// SameFile provides a mock function with given fields: filepath1, filepath2
+func (_m *FS) SameFile(filepath1 string, filepath2 string) bool {
+	ret := _m.Called(filepath1, filepath2)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(filepath1, filepath2)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // Stat provides a mock function with given fields: filepath, recursive
 func (_m *FS) Stat(filepath string, recursive bool) (*fs.FileStat, error) {
 	ret := _m.Called(filepath, recursive)
@@ -83,3 +235,17 @@ func (_m *FS) Stat(filepath string, recursive bool) (*fs.FileStat, error) {
 
 	return r0, r1
 }
+
+// WriteFile provides a mock function with given fields: filepath, data
+func (_m *FS) WriteFile(filepath string, data []byte) error {
+	ret := _m.Called(filepath, data)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(filepath, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
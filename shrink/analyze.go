@@ -0,0 +1,221 @@
+package shrink
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+type Category string
+
+const (
+	CategoryRuntimeJS    Category = "runtime js"
+	CategoryTypeScript   Category = "typescript sources"
+	CategoryDeclarations Category = "type declarations"
+	CategorySourceMaps   Category = "source maps"
+	CategoryDocs         Category = "docs"
+	CategoryTests        Category = "tests"
+	CategoryExamples     Category = "examples"
+	CategoryNative       Category = "native binaries"
+	CategoryImages       Category = "images"
+	CategoryOther        Category = "other"
+)
+
+// Categories in order of printing
+var Categories []Category = []Category{
+	CategoryRuntimeJS,
+	CategoryTypeScript,
+	CategoryDeclarations,
+	CategorySourceMaps,
+	CategoryDocs,
+	CategoryTests,
+	CategoryExamples,
+	CategoryNative,
+	CategoryImages,
+	CategoryOther,
+}
+
+// directories which define category of all nested files
+var categoryDirNames map[string]Category = map[string]Category{
+	"test":      CategoryTests,
+	"tests":     CategoryTests,
+	"__tests__": CategoryTests,
+	"__mocks__": CategoryTests,
+	"spec":      CategoryTests,
+	"example":   CategoryExamples,
+	"examples":  CategoryExamples,
+	"demo":      CategoryExamples,
+	"samples":   CategoryExamples,
+	"doc":       CategoryDocs,
+	"docs":      CategoryDocs,
+	"man":       CategoryDocs,
+}
+
+var categoryExtensions map[string]Category = map[string]Category{
+	".js":       CategoryRuntimeJS,
+	".mjs":      CategoryRuntimeJS,
+	".cjs":      CategoryRuntimeJS,
+	".ts":       CategoryTypeScript,
+	".tsx":      CategoryTypeScript,
+	".mts":      CategoryTypeScript,
+	".cts":      CategoryTypeScript,
+	".map":      CategorySourceMaps,
+	".md":       CategoryDocs,
+	".markdown": CategoryDocs,
+	".rst":      CategoryDocs,
+	".node":     CategoryNative,
+	".so":       CategoryNative,
+	".dylib":    CategoryNative,
+	".dll":      CategoryNative,
+	".exe":      CategoryNative,
+	".png":      CategoryImages,
+	".jpg":      CategoryImages,
+	".jpeg":     CategoryImages,
+	".gif":      CategoryImages,
+	".svg":      CategoryImages,
+	".ico":      CategoryImages,
+	".webp":     CategoryImages,
+	".bmp":      CategoryImages,
+}
+
+var declarationSuffixes []string = []string{".d.ts", ".d.mts", ".d.cts"}
+
+var testFileSuffixes []string = []string{".test.js", ".spec.js", ".test.ts", ".spec.ts"}
+
+// Detects category of file by its path relative to checking directory
+func categorize(relPath string) Category {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if category, exists := categoryDirNames[dir]; exists {
+			return category
+		}
+	}
+
+	name := strings.ToLower(parts[len(parts)-1])
+	for _, suffix := range testFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return CategoryTests
+		}
+	}
+	for _, suffix := range declarationSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return CategoryDeclarations
+		}
+	}
+
+	if category, exists := categoryExtensions[filepath.Ext(name)]; exists {
+		return category
+	}
+	return CategoryOther
+}
+
+type CategoryStat struct {
+	Category   Category `json:"category"`
+	Size       int64    `json:"size"`
+	FilesCount int64    `json:"files"`
+}
+
+// RuleSavings shows how much space single built-in rule releases
+type RuleSavings struct {
+	Rule       string `json:"rule"`
+	Kind       string `json:"kind"` // name or extension
+	Size       int64  `json:"size"`
+	FilesCount int64  `json:"files"`
+
+	filter     *Filter
+	matchedDir string // currently walked directory matched by rule
+}
+
+type Analysis struct {
+	Size       int64           `json:"size"`
+	FilesCount int64           `json:"files"`
+	Categories []*CategoryStat `json:"categories"`
+	Rules      []*RuleSavings  `json:"rules"`
+}
+
+// DefaultRemoveFileNames isn't estimated: package.json is read by module resolution and removing it breaks packages
+func builtinRulesSavings() []*RuleSavings {
+	rules := make([]*RuleSavings, 0)
+	for _, name := range DefaultRemoveDirNames {
+		rules = append(rules, &RuleSavings{Rule: name, Kind: "name", filter: NewFilter([]string{name}, nil, nil)})
+	}
+	for _, ext := range DefaultRemoveFileExt {
+		rules = append(rules, &RuleSavings{Rule: ext, Kind: "extension", filter: NewFilter(nil, nil, []string{ext})})
+	}
+	return rules
+}
+
+// Walks through checking path without removing anything and splits content by categories.
+// Also calculates how much space every built-in rule would release
+func (sh *Shrinker) Analyze(ctx context.Context) *Analysis {
+	analysis := &Analysis{Rules: builtinRulesSavings()}
+
+	categories := make(map[Category]*CategoryStat, len(Categories))
+	for _, category := range Categories {
+		stat := &CategoryStat{Category: category}
+		categories[category] = stat
+		analysis.Categories = append(analysis.Categories, stat)
+	}
+
+	_ = sh.walker.Walk(sh.checkPath, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if osPathname == sh.checkPath {
+			return nil
+		}
+
+		if de.IsDir() {
+			for _, rule := range analysis.Rules {
+				if rule.matchedDir != "" && isNestedPath(rule.matchedDir, osPathname) {
+					continue
+				}
+				rule.matchedDir = ""
+				if isProcessable, _ := rule.filter.Check(de); isProcessable {
+					rule.matchedDir = osPathname
+				}
+			}
+			return nil
+		}
+
+		stat, err := sh.fs.Stat(osPathname, false)
+		if err != nil {
+			sh.logger.Warn("fail stat", "path", osPathname, "error", err)
+			return nil
+		}
+
+		relPath, err := filepath.Rel(sh.checkPath, osPathname)
+		if err != nil {
+			relPath = osPathname
+		}
+
+		category := categories[categorize(relPath)]
+		category.Size += stat.Size()
+		category.FilesCount++
+		analysis.Size += stat.Size()
+		analysis.FilesCount++
+
+		for _, rule := range analysis.Rules {
+			if rule.matchedDir != "" && isNestedPath(rule.matchedDir, osPathname) {
+				rule.Size += stat.Size()
+				rule.FilesCount++
+				continue
+			}
+
+			if isProcessable, _ := rule.filter.Check(de); isProcessable {
+				rule.Size += stat.Size()
+				rule.FilesCount++
+			}
+		}
+		return nil
+	}, sh.fileFilterErrCallback)
+
+	return analysis
+}
+
+func isNestedPath(dir, path string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
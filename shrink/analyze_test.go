@@ -0,0 +1,49 @@
+package shrink
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategorizeFunc(t *testing.T) {
+	testCases := []struct {
+		path string
+		want Category
+	}{
+		{"a/index.js", CategoryRuntimeJS},
+		{"a/lib/index.mjs", CategoryRuntimeJS},
+		{"a/src/index.ts", CategoryTypeScript},
+		{"a/index.d.ts", CategoryDeclarations},
+		{"a/index.d.mts", CategoryDeclarations},
+		{"a/index.js.map", CategorySourceMaps},
+		{"a/README.md", CategoryDocs},
+		{"a/docs/api.js", CategoryDocs},
+		{"a/test/index.js", CategoryTests},
+		{"a/lib/index.spec.ts", CategoryTests},
+		{"a/__tests__/x.d.ts", CategoryTests},
+		{"a/examples/demo.png", CategoryExamples},
+		{"a/build/Release/addon.node", CategoryNative},
+		{"a/logo.SVG", CategoryImages},
+		{"a/package.json", CategoryOther},
+		{"a/LICENSE", CategoryOther},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, categorize(tc.path), fmt.Sprintf("Input: %s", tc.path))
+		})
+	}
+}
+
+func TestBuiltinRulesSavingsFunc(t *testing.T) {
+	names := make([]string, 0)
+	for _, rule := range builtinRulesSavings() {
+		names = append(names, rule.Rule)
+	}
+
+	// package.json is required by module resolution, its removal isn't suggested
+	assert.NotContains(t, names, PackageManifestName)
+	assert.Equal(t, len(DefaultRemoveDirNames)+len(DefaultRemoveFileExt), len(names))
+}
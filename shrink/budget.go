@@ -0,0 +1,108 @@
+package shrink
+
+import (
+	"context"
+	"sort"
+
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+// Budget limits size of directory tree. Zero values mean no limit
+type Budget struct {
+	MaxSize  ByteSize `yaml:"max_size"`
+	MaxFiles int64    `yaml:"max_files"`
+}
+
+type BudgetViolation struct {
+	Metric string // size or files
+	Limit  int64
+	Actual int64
+}
+
+// Returns list of exceeded limits, empty list means tree fits the budget
+func (b *Budget) Check(m *TreeMeasure) []*BudgetViolation {
+	violations := make([]*BudgetViolation, 0)
+	if b.MaxSize > 0 && m.Size > int64(b.MaxSize) {
+		violations = append(violations, &BudgetViolation{Metric: "size", Limit: int64(b.MaxSize), Actual: m.Size})
+	}
+	if b.MaxFiles > 0 && m.FilesCount > b.MaxFiles {
+		violations = append(violations, &BudgetViolation{Metric: "files", Limit: b.MaxFiles, Actual: m.FilesCount})
+	}
+	return violations
+}
+
+type PackageMeasure struct {
+	Name       string // empty for files outside of node_modules
+	Size       int64
+	FilesCount int64
+}
+
+type TreeMeasure struct {
+	Size       int64
+	FilesCount int64
+	Packages   []*PackageMeasure // sorted by size from the biggest one
+}
+
+// Measures size of checking path by packages. With dryRun entries matched by filter
+// are not counted, so result shows tree as it would be after Clean
+func (sh *Shrinker) Measure(ctx context.Context, dryRun bool) *TreeMeasure {
+	measure := &TreeMeasure{}
+	packages := make(map[string]*PackageMeasure)
+
+	_ = sh.walker.Walk(sh.checkPath, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// the same walk as Clean does: matched entries are removed, excluded directories are kept as a whole
+		var walkErr error
+		if dryRun {
+			match := sh.match(ctx, osPathname, de)
+			if match.Removes() {
+				if de.IsDir() {
+					return SkipDirError
+				}
+				return nil
+			}
+			if match.Excludes() && de.IsDir() {
+				walkErr = SkipDirError
+			}
+		}
+
+		if de.IsDir() && walkErr == nil {
+			return nil
+		}
+
+		stat, err := sh.fs.Stat(osPathname, de.IsDir())
+		if err != nil {
+			sh.logger.Warn("fail stat", "path", osPathname, "error", err)
+			return walkErr
+		}
+
+		name := packageNameFromPath(osPathname)
+		pkg, exists := packages[name]
+		if !exists {
+			pkg = &PackageMeasure{Name: name}
+			packages[name] = pkg
+		}
+
+		pkg.Size += stat.Size()
+		pkg.FilesCount += stat.FilesCount()
+		measure.Size += stat.Size()
+		measure.FilesCount += stat.FilesCount()
+		return walkErr
+	}, sh.fileFilterErrCallback)
+
+	measure.Packages = make([]*PackageMeasure, 0, len(packages))
+	for _, pkg := range packages {
+		measure.Packages = append(measure.Packages, pkg)
+	}
+	sort.Slice(measure.Packages, func(i, j int) bool {
+		if measure.Packages[i].Size == measure.Packages[j].Size {
+			return measure.Packages[i].Name < measure.Packages[j].Name
+		}
+		return measure.Packages[i].Size > measure.Packages[j].Size
+	})
+
+	return measure
+}
@@ -0,0 +1,31 @@
+package shrink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetCheckFunc(t *testing.T) {
+	measure := &TreeMeasure{Size: 2048, FilesCount: 10}
+
+	testCases := []struct {
+		alias  string
+		budget *Budget
+		want   []*BudgetViolation
+	}{
+		{"Test empty budget", &Budget{}, []*BudgetViolation{}},
+		{"Test fitting budget", &Budget{MaxSize: 2048, MaxFiles: 10}, []*BudgetViolation{}},
+		{"Test exceeded size", &Budget{MaxSize: 1024}, []*BudgetViolation{{Metric: "size", Limit: 1024, Actual: 2048}}},
+		{"Test exceeded files and size", &Budget{MaxSize: 1024, MaxFiles: 5}, []*BudgetViolation{
+			{Metric: "size", Limit: 1024, Actual: 2048},
+			{Metric: "files", Limit: 5, Actual: 10},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.budget.Check(measure))
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package shrink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/icecream78/node_shrinker/fs"
+)
+
+const DefaultCacheFileName = ".node_shrinker-cache"
+
+// cache written with other version is ignored
+const cacheVersion = 2
+
+// Returns path of state cache inside node_modules directory of project which contains checking directory
+func DefaultCachePath(checkPath string) string {
+	return filepath.Join(ProjectPath(checkPath), nodeModulesDirName, DefaultCacheFileName)
+}
+
+// cacheEntry is result of shrinking one package. Package installed again has the same ID, so it's told apart
+// by inode and status change time of package.json (or pnpm store entry directory), which are changed
+// by installing and aren't changed by removing other entries of package.
+//
+// Entry is also invalidated, so package is checked again, when its package.json is:
+//   - replaced with link by dedupe, which renames temporary link over it and changes inode;
+//   - hardlinked by dedupe as original of duplicates, which changes count of links and so status change time;
+//   - touched, chmoded or rewritten by anything else.
+//
+// Removing or linking other files of package keeps entry valid
+type cacheEntry struct {
+	ID         string `json:"id"`    // name@version of package or name of pnpm store entry
+	Inode      uint64 `json:"inode"` // zero on platforms without inodes
+	Changed    int64  `json:"changed"`
+	RulesHash  string `json:"rules"` // hash of rules and conditions package was shrunk with
+	Size       int64  `json:"size"`
+	FilesCount int64  `json:"files"`
+
+	failed bool // some entries of package weren't removed, so it must be checked again
+}
+
+type stateCache struct {
+	Version     int                    `json:"version"`
+	NodeModules uint64                 `json:"node_modules"` // inode of node_modules directory of project
+	Packages    map[string]*cacheEntry `json:"packages"`     // keyed by path of package relative to project root
+}
+
+// Hash of everything that decides which entries are removed. Custom matchers are identified
+// by description if they implement fmt.Stringer, otherwise by type only
+func rulesHash(filter *Filter, matchers []Matcher, conditions Conditions) string {
+	lines := make([]string, 0, len(filter.Rules())+len(matchers)+1)
+	for _, rule := range filter.Rules() {
+		lines = append(lines, fmt.Sprintf("%s\t%s\t%s", rule.Kind, rule.Pattern, rule.Package))
+	}
+	for _, pattern := range filter.overridePackages() {
+		lines = append(lines, fmt.Sprintf("override\t%s", pattern))
+	}
+	for _, matcher := range matchers {
+		if stringer, ok := matcher.(fmt.Stringer); ok {
+			lines = append(lines, fmt.Sprintf("matcher\t%T\t%s", matcher, stringer.String()))
+		} else {
+			lines = append(lines, fmt.Sprintf("matcher\t%T", matcher))
+		}
+	}
+	sort.Strings(lines)
+	lines = append(lines, fmt.Sprintf("conditions\t%+v", conditions))
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns inode of node_modules directory of project, zero if it's unknown
+func (sh *Shrinker) nodeModulesInode() uint64 {
+	stat, err := sh.fs.Stat(filepath.Join(sh.projectPath(), nodeModulesDirName), false)
+	if err != nil {
+		return 0
+	}
+	return stat.Inode()
+}
+
+// Reads cache of previous run. Cache is dropped if node_modules directory was created again
+// since then (npm ci, rm -rf node_modules), even if cache file is placed outside of it
+func (sh *Shrinker) readCache(nodeModules uint64) *stateCache {
+	cache := &stateCache{Version: cacheVersion, NodeModules: nodeModules, Packages: make(map[string]*cacheEntry)}
+
+	data, err := sh.fs.ReadFile(sh.cacheFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			sh.logger.Warn("fail read cache", "path", sh.cacheFile, "error", err)
+		}
+		return cache
+	}
+
+	previous := &stateCache{}
+	if err := json.Unmarshal(data, previous); err != nil || previous.Version != cacheVersion || previous.Packages == nil {
+		sh.logger.Warn("ignoring invalid cache", "path", sh.cacheFile, "error", err)
+		return cache
+	}
+	if previous.NodeModules != nodeModules {
+		sh.logger.Debug("ignoring cache of removed node_modules", "path", sh.cacheFile)
+		return cache
+	}
+	return previous
+}
+
+// cacheRun tracks packages during one Clean. Walking goroutine checks and registers packages,
+// cleaners add results of removed entries concurrently. Results are recorded only if caching is enabled,
+// so methods called from cleaners accept nil run
+type cacheRun struct {
+	projectPath string
+	rulesHash   string
+	nodeModules uint64
+	previous    *stateCache
+
+	mu       sync.Mutex
+	packages map[string]*cacheEntry // keyed by absolute path of package root
+	nested   []string               // node_modules directories of skipped packages, which must be walked
+}
+
+func (sh *Shrinker) newCacheRun() *cacheRun {
+	if sh.cacheFile == "" {
+		return nil
+	}
+	if sh.conditions.dependsOnTime() {
+		// unchanged package may have entries satisfying age conditions later, so results can't be reused
+		sh.logger.Debug("cache is disabled by age conditions", "path", sh.cacheFile)
+		return nil
+	}
+
+	nodeModules := sh.nodeModulesInode()
+	return &cacheRun{
+		projectPath: sh.projectPath(),
+		rulesHash:   sh.rulesHash,
+		nodeModules: nodeModules,
+		previous:    sh.readCache(nodeModules),
+		packages:    make(map[string]*cacheEntry),
+	}
+}
+
+func (run *cacheRun) key(root string) string {
+	relPath, err := filepath.Rel(run.projectPath, root)
+	if err != nil {
+		return filepath.ToSlash(root)
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// Returns installed state of package which root is provided directory: identifier, inode and status change time.
+// Returns nil if directory isn't a package root
+func (sh *Shrinker) packageState(dir string) *cacheEntry {
+	if packageRootFromPath(dir) != dir {
+		return nil
+	}
+	if filepath.Base(filepath.Dir(dir)) == pnpmStoreDirName {
+		// name of store entry contains version and hash of peer dependencies
+		return sh.withInstallStamp(&cacheEntry{ID: filepath.Base(dir)}, dir)
+	}
+
+	manifestPath := filepath.Join(dir, PackageManifestName)
+	data, err := sh.fs.ReadFile(manifestPath)
+	if err != nil {
+		return nil
+	}
+	manifest, err := parsePackageManifest(data)
+	if err != nil || manifest.Name == "" {
+		return nil
+	}
+	return sh.withInstallStamp(&cacheEntry{ID: manifest.ID()}, manifestPath)
+}
+
+// Sets inode and status change time of entry created by installing package
+func (sh *Shrinker) withInstallStamp(state *cacheEntry, path string) *cacheEntry {
+	stat, err := sh.fs.Stat(path, false)
+	if err != nil {
+		return nil
+	}
+	state.Inode = stat.Inode()
+	state.Changed = stat.ChangeTime().UnixNano()
+	return state
+}
+
+// Checks is package unchanged since previous run and registers it. Cached packages are kept in cache as is
+func (run *cacheRun) visit(root string, state *cacheEntry) (isCached bool) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	previous, exists := run.previous.Packages[run.key(root)]
+	if exists && previous.ID == state.ID && previous.Inode == state.Inode && previous.Changed == state.Changed && previous.RulesHash == run.rulesHash {
+		run.packages[root] = previous
+		return true
+	}
+
+	run.packages[root] = &cacheEntry{ID: state.ID, Inode: state.Inode, Changed: state.Changed, RulesHash: run.rulesHash}
+	return false
+}
+
+func (run *cacheRun) addNested(dir string) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	run.nested = append(run.nested, dir)
+}
+
+// Returns node_modules directories of skipped packages, which exist, and forgets them
+func (sh *Shrinker) takeNested(run *cacheRun) []string {
+	run.mu.Lock()
+	nested := run.nested
+	run.nested = nil
+	run.mu.Unlock()
+
+	existing := make([]string, 0, len(nested))
+	for _, dir := range nested {
+		if pathExists(sh.fs, dir) {
+			existing = append(existing, dir)
+		}
+	}
+	return existing
+}
+
+// Adds removed entry to result of package which owns it
+func (run *cacheRun) addRemoved(osPathname string, stat *FileStat) {
+	if run == nil {
+		return
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	if entry, exists := run.packages[packageRootFromPath(osPathname)]; exists {
+		entry.Size += stat.Size()
+		entry.FilesCount += stat.FilesCount()
+	}
+}
+
+// Marks package which owns entry as failed, it isn't cached then
+func (run *cacheRun) addError(osPathname string) {
+	if run == nil {
+		return
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	if entry, exists := run.packages[packageRootFromPath(osPathname)]; exists {
+		entry.failed = true
+	}
+}
+
+// Builds new state from previous one: packages inside walked directories are replaced with visited ones,
+// so removed packages disappear from cache and packages outside of walked directories are kept
+func (run *cacheRun) result(walked []string) *stateCache {
+	cache := &stateCache{Version: cacheVersion, NodeModules: run.nodeModules, Packages: make(map[string]*cacheEntry)}
+
+	for key, entry := range run.previous.Packages {
+		root := filepath.Join(run.projectPath, filepath.FromSlash(key))
+		isWalked := false
+		for _, dir := range walked {
+			if root == dir || strings.HasPrefix(root, dir+string(filepath.Separator)) {
+				isWalked = true
+				break
+			}
+		}
+		if !isWalked {
+			cache.Packages[key] = entry
+		}
+	}
+
+	for root, entry := range run.packages {
+		if !entry.failed {
+			cache.Packages[run.key(root)] = entry
+		}
+	}
+	return cache
+}
+
+func (sh *Shrinker) writeCache(cache *stateCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return sh.fs.WriteFile(sh.cacheFile, data)
+}
@@ -0,0 +1,176 @@
+package shrink
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCacheFile = "/project/node_modules/" + DefaultCacheFileName
+
+func newCacheTree() fs.MemTree {
+	return fs.MemTree{
+		"node_modules/a/package.json":                    fs.MemFile(`{"name": "a", "version": "1.0.0"}`),
+		"node_modules/a/test/a.spec.js":                  fs.MemFile("0123456789"),
+		"node_modules/a/node_modules/b/package.json":     fs.MemFile(`{"name": "b", "version": "1.0.0"}`),
+		"node_modules/a/node_modules/b/index.d.ts":       fs.MemFile("b"),
+		"node_modules/@scope/c/package.json":             fs.MemFile(`{"name": "@scope/c", "version": "2.0.0"}`),
+		"node_modules/@scope/c/README.md":                fs.MemFile("# c"),
+		"node_modules/.pnpm/d@1.0.0/node_modules/d/x.ts": fs.MemFile("d"),
+		"node_modules/locked/package.json":               fs.MemFile(`{"name": "locked", "version": "1.0.0"}`),
+		"node_modules/locked/lib":                        {IsDir: true, Denied: true},
+	}
+}
+
+func readTestCache(t *testing.T, memFS *fs.MemFS) *stateCache {
+	data, err := memFS.ReadFile(testCacheFile)
+	if !assert.Nil(t, err) {
+		return &stateCache{}
+	}
+
+	cache := &stateCache{}
+	assert.Nil(t, json.Unmarshal(data, cache))
+	return cache
+}
+
+func TestCleanCacheFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newCacheTree())
+	sh := newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile})
+
+	stats := sh.Clean(context.TODO())
+	assert.Equal(t, int64(4), stats.FilesCount())
+
+	cache := readTestCache(t, memFS)
+	assert.Equal(t, cacheVersion, cache.Version)
+	ids := make(map[string]string)
+	for key, entry := range cache.Packages {
+		ids[key] = entry.ID
+		assert.Equal(t, sh.rulesHash, entry.RulesHash, key)
+	}
+	// locked package isn't cached, because it wasn't fully walked
+	assert.Equal(t, map[string]string{
+		"node_modules/a":                "a@1.0.0",
+		"node_modules/a/node_modules/b": "b@1.0.0",
+		"node_modules/@scope/c":         "@scope/c@2.0.0",
+		"node_modules/.pnpm/d@1.0.0":    "d@1.0.0",
+	}, ids)
+	assert.Equal(t, int64(10), cache.Packages["node_modules/a"].Size)
+	assert.Equal(t, int64(1), cache.Packages["node_modules/a"].FilesCount)
+
+	// unchanged packages are skipped, changed ones and nested packages of skipped ones are checked
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/a/index.ts", []byte("a")))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/a/node_modules/b/package.json", []byte(`{"name": "b", "version": "1.1.0"}`)))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/a/node_modules/b/index.d.ts", []byte("b")))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/@scope/c/index.ts", []byte("c")))
+
+	stats = sh.Clean(context.TODO())
+	assert.Equal(t, int64(1), stats.FilesCount())
+	assert.True(t, pathExists(memFS, "/project/node_modules/a/index.ts"))
+	assert.False(t, pathExists(memFS, "/project/node_modules/a/node_modules/b/index.d.ts"))
+	assert.True(t, pathExists(memFS, "/project/node_modules/@scope/c/index.ts"))
+
+	cache = readTestCache(t, memFS)
+	assert.Equal(t, "b@1.1.0", cache.Packages["node_modules/a/node_modules/b"].ID)
+	assert.Equal(t, int64(10), cache.Packages["node_modules/a"].Size, "result of skipped package is kept")
+
+	// other rules invalidate cache
+	sh = newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile, Rules: NewRules(RuleInclude, SourceFlag, []string{"index.ts"})})
+	stats = sh.Clean(context.TODO())
+	assert.Equal(t, int64(2), stats.FilesCount())
+	assert.False(t, pathExists(memFS, "/project/node_modules/a/index.ts"))
+}
+
+func TestCacheReinstalledPackageFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newCacheTree())
+	sh := newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile})
+	sh.Clean(context.TODO())
+
+	// the same version is installed again without shrinking (npm install after rm -rf of package)
+	assert.Nil(t, memFS.RemoveAll("/project/node_modules/a"))
+	assert.Nil(t, memFS.MkdirAll("/project/node_modules/a/test"))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/a/package.json", []byte(`{"name": "a", "version": "1.0.0"}`)))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/a/test/a.spec.js", []byte("0123456789")))
+	assert.Nil(t, memFS.RemoveAll("/project/node_modules/.pnpm/d@1.0.0"))
+	assert.Nil(t, memFS.MkdirAll("/project/node_modules/.pnpm/d@1.0.0/node_modules/d"))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/.pnpm/d@1.0.0/node_modules/d/x.ts", []byte("d")))
+
+	stats := sh.Clean(context.TODO())
+	assert.Equal(t, int64(2), stats.FilesCount())
+	assert.False(t, pathExists(memFS, "/project/node_modules/a/test"))
+	assert.False(t, pathExists(memFS, "/project/node_modules/.pnpm/d@1.0.0/node_modules/d/x.ts"))
+}
+
+func TestCacheRecreatedNodeModulesFunc(t *testing.T) {
+	cacheFile := "/project/.cache/" + DefaultCacheFileName
+	tree := newCacheTree()
+	tree[".cache"] = fs.MemDir()
+	memFS := fs.NewMemFS("/project", tree)
+	sh := newMemShrinker(t, memFS, &Config{CacheFile: cacheFile})
+	sh.Clean(context.TODO())
+	data, err := memFS.ReadFile(cacheFile)
+	assert.Nil(t, err)
+
+	// node_modules is created again with the same content (npm ci), cache outside of it is dropped
+	tree[".cache/"+DefaultCacheFileName] = fs.MemFile(string(data))
+	memFS = fs.NewMemFS("/project", tree)
+	sh = newMemShrinker(t, memFS, &Config{CacheFile: cacheFile})
+	stats := sh.Clean(context.TODO())
+	assert.Equal(t, int64(4), stats.FilesCount())
+}
+
+func TestCacheAgeConditionsFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newCacheTree())
+	sh := newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile})
+	sh.Clean(context.TODO())
+	cached, err := memFS.ReadFile(testCacheFile)
+	assert.Nil(t, err)
+
+	// age of entries changes as time goes, so packages are checked without cache and it isn't rewritten
+	assert.Nil(t, memFS.MkdirAll("/project/node_modules/a/test"))
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/a/test/b.spec.js", []byte("0123456789")))
+	sh = newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile, Conditions: Conditions{NewerThan: time.Hour}})
+	stats := sh.Clean(context.TODO())
+	assert.Equal(t, int64(1), stats.FilesCount())
+	assert.False(t, pathExists(memFS, "/project/node_modules/a/test"))
+
+	data, err := memFS.ReadFile(testCacheFile)
+	assert.Nil(t, err)
+	assert.Equal(t, cached, data)
+}
+
+func TestCleanDirsCacheFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newCacheTree())
+	sh := newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile})
+	sh.Clean(context.TODO())
+
+	// packages outside of cleaned directories are kept, removed ones are forgotten
+	assert.Nil(t, memFS.RemoveAll("/project/node_modules/a/node_modules"))
+	sh.CleanDirs(context.TODO(), []string{"/project/node_modules/a"})
+
+	cache := readTestCache(t, memFS)
+	assert.Contains(t, cache.Packages, "node_modules/a")
+	assert.Contains(t, cache.Packages, "node_modules/@scope/c")
+	assert.NotContains(t, cache.Packages, "node_modules/a/node_modules/b")
+}
+
+func TestCacheFileFunc(t *testing.T) {
+	tree := newCacheTree()
+	tree["node_modules/"+DefaultCacheFileName] = fs.MemFile("not a json")
+	memFS := fs.NewMemFS("/project", tree)
+
+	// invalid cache is ignored, cache file itself is never removed
+	sh := newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile, Rules: NewRules(RuleInclude, SourceFlag, []string{"cache$"})})
+	stats := sh.Clean(context.TODO())
+	assert.Equal(t, int64(4), stats.FilesCount())
+	assert.Equal(t, 4, len(readTestCache(t, memFS).Packages))
+
+	// dry run doesn't use cache
+	memFS = fs.NewMemFS("/project", newCacheTree())
+	sh = newMemShrinker(t, memFS, &Config{CacheFile: testCacheFile, DryRun: true})
+	sh.DryRun(context.TODO())
+	assert.False(t, pathExists(memFS, testCacheFile))
+}
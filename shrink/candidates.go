@@ -0,0 +1,82 @@
+package shrink
+
+import (
+	"context"
+	"sort"
+
+	. "github.com/icecream78/node_shrinker/fs"
+)
+
+// Candidate is a file or directory matched by filter, which can be removed
+type Candidate struct {
+	Path       string
+	Name       string
+	Package    string // name of package which owns candidate, empty for files outside of node_modules
+	IsDir      bool
+	Size       int64
+	FilesCount int64
+}
+
+// Returns all entries matched by filter with their sizes, sorted by size from the biggest one.
+// Nothing is removed, selected candidates can be passed to Remove
+func (sh *Shrinker) Candidates(ctx context.Context) []*Candidate {
+	candidates := make([]*Candidate, 0)
+
+	filesCh := sh.inspectPath(ctx, sh.checkPath)
+	for obj := range filesCh {
+		if ctx.Err() != nil {
+			continue // drain channel so walker is able to finish
+		}
+
+		stat, err := sh.fs.Stat(obj.fullpath, obj.isDir)
+		if err != nil {
+			sh.logger.Warn("fail stat", "path", obj.fullpath, "error", err)
+			continue
+		}
+
+		candidates = append(candidates, &Candidate{
+			Path:       obj.fullpath,
+			Name:       obj.filename,
+			Package:    packageNameFromPath(obj.fullpath),
+			IsDir:      obj.isDir,
+			Size:       stat.Size(),
+			FilesCount: stat.FilesCount(),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Size == candidates[j].Size {
+			return candidates[i].Path < candidates[j].Path
+		}
+		return candidates[i].Size > candidates[j].Size
+	})
+	return candidates
+}
+
+// Removes provided candidates with the same cleaners pipeline as Clean does
+func (sh *Shrinker) Remove(ctx context.Context, candidates []*Candidate) (stats *FileStat) {
+	sh.progress = newProgressTracker()
+	sh.progress.AddMatchedCount(int64(len(candidates)))
+	stopProgress := sh.progress.Report(sh.onProgress, sh.progressInterval)
+
+	filesCh := make(chan *removeObjInfo)
+	go func(ch chan *removeObjInfo) {
+		defer close(ch)
+
+		for _, candidate := range candidates {
+			select {
+			case ch <- &removeObjInfo{isDir: candidate.IsDir, filename: candidate.Name, fullpath: candidate.Path}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(filesCh)
+
+	removeCh := sh.runCleaners(ctx, filesCh)
+	statsCh := sh.runStatGrabber(ctx, removeCh)
+
+	stats = <-statsCh
+	stopProgress()
+	sh.observer.OnDone(stats)
+	return stats
+}
@@ -0,0 +1,56 @@
+package shrink
+
+import (
+	"fmt"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Conditions restrict entries matched by remove rules with their metadata. Zero values mean no limit.
+// Directories are checked by total size of their content and modification time of directory itself
+type Conditions struct {
+	MinSize   int64
+	MaxSize   int64
+	OlderThan time.Duration // entry wasn't modified during this duration
+	NewerThan time.Duration // entry was modified during this duration
+}
+
+func (c *Conditions) isEmpty() bool {
+	return c.MinSize <= 0 && c.MaxSize <= 0 && c.OlderThan <= 0 && c.NewerThan <= 0
+}
+
+// Age conditions give other results as time goes, even for unchanged entries
+func (c *Conditions) dependsOnTime() bool {
+	return c.OlderThan > 0 || c.NewerThan > 0
+}
+
+// Returns reason why entry doesn't satisfy conditions, empty string if it does.
+// Metadata is read only for conditions which are set
+func (c *Conditions) check(entry *Entry, now time.Time) string {
+	if c.MinSize > 0 || c.MaxSize > 0 {
+		size := entry.Size()
+		if c.MinSize > 0 && size < c.MinSize {
+			return fmt.Sprintf("size %s is less than %s", humanize.Bytes(uint64(size)), humanize.Bytes(uint64(c.MinSize)))
+		}
+		if c.MaxSize > 0 && size > c.MaxSize {
+			return fmt.Sprintf("size %s is greater than %s", humanize.Bytes(uint64(size)), humanize.Bytes(uint64(c.MaxSize)))
+		}
+	}
+
+	if c.OlderThan > 0 || c.NewerThan > 0 {
+		modTime := entry.ModTime()
+		if modTime.IsZero() {
+			return "modification time is unknown"
+		}
+
+		age := now.Sub(modTime)
+		if c.OlderThan > 0 && age <= c.OlderThan {
+			return fmt.Sprintf("modified %s ago, not older than %s", age.Round(time.Second), c.OlderThan)
+		}
+		if c.NewerThan > 0 && age > c.NewerThan {
+			return fmt.Sprintf("modified %s ago, not newer than %s", age.Round(time.Second), c.NewerThan)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package shrink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/walker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionsFunc(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	newFileEntry := func(size int64, modTime time.Time) *Entry {
+		return &Entry{Name: "file", info: NewFileInfoWithStat("file", 0644, size, modTime)}
+	}
+
+	testCases := []struct {
+		alias      string
+		conditions Conditions
+		entry      *Entry
+		wantPassed bool
+	}{
+		{"Test empty conditions", Conditions{}, newFileEntry(10, now), true},
+		{"Test min size passed", Conditions{MinSize: 10}, newFileEntry(10, now), true},
+		{"Test min size failed", Conditions{MinSize: 11}, newFileEntry(10, now), false},
+		{"Test max size passed", Conditions{MaxSize: 10}, newFileEntry(10, now), true},
+		{"Test max size failed", Conditions{MaxSize: 9}, newFileEntry(10, now), false},
+		{"Test older than passed", Conditions{OlderThan: day}, newFileEntry(10, now.Add(-2*day)), true},
+		{"Test older than failed", Conditions{OlderThan: day}, newFileEntry(10, now.Add(-time.Hour)), false},
+		{"Test newer than passed", Conditions{NewerThan: day}, newFileEntry(10, now.Add(-time.Hour)), true},
+		{"Test newer than failed", Conditions{NewerThan: day}, newFileEntry(10, now.Add(-2*day)), false},
+		{"Test unknown modification time", Conditions{OlderThan: day}, &Entry{info: NewFileInfo("file", false, true)}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			reason := tc.conditions.check(tc.entry, now)
+			assert.Equal(t, tc.wantPassed, reason == "", reason)
+		})
+	}
+}
+
+func TestMatchWithConditionsFunc(t *testing.T) {
+	sh := &Shrinker{
+		checkPath:  "/p",
+		filter:     NewFilter(nil, nil, []string{".md"}),
+		conditions: Conditions{MinSize: 1024},
+	}
+
+	match := sh.match(context.Background(), "/p/big.md", NewFileInfoWithStat("big.md", 0644, 2048, time.Now()))
+	assert.True(t, match.Removes())
+
+	match = sh.match(context.Background(), "/p/small.md", NewFileInfoWithStat("small.md", 0644, 10, time.Now()))
+	assert.False(t, match.Removes())
+	assert.False(t, match.Excludes())
+	assert.Equal(t, MatchExtension, match.Kind)
+	assert.NotEmpty(t, match.Rejected)
+}
@@ -1,11 +1,42 @@
 package shrink
 
+import (
+	"io"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
 type Config struct {
 	VerboseOutput  bool
+	Logger         Logger    // nil means text output to stderr, only errors are written without VerboseOutput
+	Observer       Observer  // receives events of every checked entry, nil means NopObserver
+	Output         io.Writer // tree of checking directory printed by DryRun, stdout by default. It isn't a log, Logger format isn't applied
 	DryRun         bool
 	ConcurentLimit int
 	CheckPath      string
 	RemoveFileExt  []string
 	ExcludeNames   []string
 	IncludeNames   []string
+	Rules          []*Rule // rules with known source, used together with name lists above
+	// package names or globs, which subtrees are checked only with package rules from Rules, without global ones
+	OverridePackages []string
+	Matchers         []Matcher  // custom matchers checked together with rules
+	Conditions       Conditions // size and age limits of entries matched by rules and matchers
+	LinkMode         LinkMode   // how duplicates are replaced by Dedupe
+	NoticesFile      string     // path to file where license texts are collected before removing. Empty string disables collecting
+	CacheFile        string     // path to state cache, Clean skips packages unchanged since previous run. Empty string disables caching
+	FS               FS         // file system where checking directory lives, OS file system by default
+	Walker           Walker     // walker of FS, directory walker of OS file system by default
+
+	OnProgress       ProgressFunc  // receives counters during Clean and Remove, nil disables reporting
+	ProgressInterval time.Duration // DefaultProgressInterval if not set
+}
+
+func (cfg *Config) rules() []*Rule {
+	rules := NewRules(RuleExclude, SourceConfig, cfg.ExcludeNames)
+	rules = append(rules, NewRules(RuleInclude, SourceConfig, cfg.IncludeNames)...)
+	rules = append(rules, NewRules(RuleExtension, SourceConfig, cfg.RemoveFileExt)...)
+	return append(rules, cfg.Rules...)
 }
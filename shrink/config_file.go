@@ -0,0 +1,165 @@
+package shrink
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	. "github.com/icecream78/node_shrinker/fs"
+
+	humanize "github.com/dustin/go-humanize"
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultConfigFileName = ".node_shrinker.yml"
+
+// ByteSize is a size in bytes, which can be written in human readable format (150MB, 1.5 GiB)
+type ByteSize int64
+
+func ParseByteSize(input string) (ByteSize, error) {
+	size, err := humanize.ParseBytes(input)
+	if err != nil {
+		return 0, err
+	}
+	return ByteSize(size), nil
+}
+
+func (bs *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseByteSize(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid size %q", value.Line, value.Value)
+	}
+	*bs = size
+	return nil
+}
+
+func (bs ByteSize) String() string {
+	return humanize.Bytes(uint64(bs))
+}
+
+// PackageRules are rules applied inside subtree of package. They extend global rules,
+// unless Override is set. Exclude "*" keeps whole package
+type PackageRules struct {
+	Include  []string `yaml:"include"`
+	Exclude  []string `yaml:"exclude"`
+	Ext      []string `yaml:"ext"`
+	Override bool     `yaml:"override"`
+}
+
+// FileConfig is a content of .node_shrinker.yml file
+type FileConfig struct {
+	Include  []string                 `yaml:"include"`
+	Exclude  []string                 `yaml:"exclude"`
+	Ext      []string                 `yaml:"ext"`
+	Budgets  map[string]*Budget       `yaml:"budgets"`  // directory relative to config file -> budget
+	Packages map[string]*PackageRules `yaml:"packages"` // package name or glob (@scope/*) -> rules
+	Platform string                   `yaml:"platform"` // target platform of presets, which remove native binaries of other ones
+
+	path string
+}
+
+func LoadFileConfig(path string) (*FileConfig, error) {
+	return ReadFileConfig(NewFS(), path)
+}
+
+// Reads config file from provided file system
+func ReadFileConfig(fs FS, path string) (*FileConfig, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := FileConfig{path: path}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("fail parse %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// Returns budgets keyed by directory path. Relative pathes are resolved from config file directory
+func (fc *FileConfig) ResolvedBudgets() map[string]*Budget {
+	budgets := make(map[string]*Budget, len(fc.Budgets))
+	for dir, budget := range fc.Budgets {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(fc.path), dir)
+		}
+		budgets[dir] = budget
+	}
+	return budgets
+}
+
+// Returns sorted names and globs of packages sections
+func (fc *FileConfig) packagePatterns() []string {
+	patterns := make([]string, 0, len(fc.Packages))
+	for pattern := range fc.Packages {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// Returns rules of packages sections. Sections are ordered by package pattern
+func (fc *FileConfig) PackagesRules() []*Rule {
+	rules := make([]*Rule, 0)
+	for _, pattern := range fc.packagePatterns() {
+		section := fc.Packages[pattern]
+		if section == nil {
+			continue
+		}
+		rules = append(rules, NewPackageRules(pattern, RuleExclude, SourceConfigFile, section.Exclude)...)
+		rules = append(rules, NewPackageRules(pattern, RuleInclude, SourceConfigFile, section.Include)...)
+		rules = append(rules, NewPackageRules(pattern, RuleExtension, SourceConfigFile, section.Ext)...)
+	}
+	return rules
+}
+
+// Returns package patterns which sections override global rules
+func (fc *FileConfig) OverridePackages() []string {
+	patterns := make([]string, 0)
+	for _, pattern := range fc.packagePatterns() {
+		if section := fc.Packages[pattern]; section != nil && section.Override {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// Returns content of config file with default rules and comments about every section
+func ConfigSkeleton() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# node_shrinker config, flags with the same names are added to these lists\n\n")
+
+	buf.WriteString("# names of files and directories to remove, regular expressions are supported\n")
+	writeYAMLList(&buf, "include", DefaultRemoveDirNames)
+
+	buf.WriteString("\n# extensions of files to remove\n")
+	writeYAMLList(&buf, "ext", DefaultRemoveFileExt)
+
+	buf.WriteString("\n# names of files and directories to keep with all nested entries, they win over include and ext\n")
+	writeYAMLList(&buf, "exclude", nil)
+
+	buf.WriteString("\n# rules applied only inside packages matching name or glob, they extend global rules unless override is set.\n")
+	buf.WriteString("# exclude \"*\" keeps whole package\n")
+	buf.WriteString("# packages:\n#   swagger-ui-dist:\n#     exclude: [\"*\"]\n#   \"@aws-sdk/*\":\n#     include: [\"dist-es\"]\n#     override: true\n")
+
+	buf.WriteString("\n# size limits of directories relative to this file, checked by \"check\" command\n")
+	buf.WriteString("# budgets:\n#   node_modules:\n#     max_size: 200MB\n")
+
+	buf.WriteString("\n# platform where packages run (linux, darwin, win32), electron preset keeps only its native binaries.\n")
+	buf.WriteString("# Platform of current OS is used by default\n")
+	buf.WriteString("# platform: linux\n")
+	return buf.Bytes()
+}
+
+func writeYAMLList(buf *bytes.Buffer, key string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(buf, "%s: []\n", key)
+		return
+	}
+
+	fmt.Fprintf(buf, "%s:\n", key)
+	for _, value := range values {
+		fmt.Fprintf(buf, "  - %q\n", value)
+	}
+}
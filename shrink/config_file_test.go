@@ -0,0 +1,54 @@
+package shrink
+
+import (
+	"testing"
+
+	"github.com/icecream78/node_shrinker/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFileConfigFunc(t *testing.T) {
+	osMock := new(mocks.FS)
+
+	osMock.On("ReadFile", "/project/.node_shrinker.yml").Return([]byte(`
+include: [test, docs]
+exclude: [important]
+ext: [.ts]
+budgets:
+  node_modules:
+    max_size: 150MB
+    max_files: 20000
+  /abs/dir:
+    max_size: 1KiB
+packages:
+  swagger-ui-dist:
+    exclude: ["*"]
+  "@aws-sdk/*":
+    include: [dist-es]
+    override: true
+`), nil)
+	osMock.On("ReadFile", "/project/broken.yml").Return([]byte(`
+budgets:
+  node_modules:
+    max_size: many
+`), nil)
+
+	cfg, err := ReadFileConfig(osMock, "/project/.node_shrinker.yml")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test", "docs"}, cfg.Include)
+	assert.Equal(t, []string{"important"}, cfg.Exclude)
+	assert.Equal(t, []string{".ts"}, cfg.Ext)
+	assert.Equal(t, map[string]*Budget{
+		"/project/node_modules": {MaxSize: 150000000, MaxFiles: 20000},
+		"/abs/dir":              {MaxSize: 1024},
+	}, cfg.ResolvedBudgets())
+	assert.Equal(t, []*Rule{
+		{Kind: RuleInclude, Pattern: "dist-es", Source: SourceConfigFile, Package: "@aws-sdk/*"},
+		{Kind: RuleExclude, Pattern: PackageKeepAll, Source: SourceConfigFile, Package: "swagger-ui-dist"},
+	}, cfg.PackagesRules())
+	assert.Equal(t, []string{"@aws-sdk/*"}, cfg.OverridePackages())
+
+	_, err = ReadFileConfig(osMock, "/project/broken.yml")
+	assert.NotNil(t, err)
+}
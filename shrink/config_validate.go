@@ -0,0 +1,338 @@
+package shrink
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigProblemKind string
+
+const (
+	ProblemUnknownKey     ConfigProblemKind = "unknown key"
+	ProblemInvalidPattern ConfigProblemKind = "invalid pattern"
+	ProblemDuplicate      ConfigProblemKind = "duplicated rule"
+	ProblemOverlap        ConfigProblemKind = "overlapping rules"
+	ProblemNeverMatches   ConfigProblemKind = "never matches"
+)
+
+type ConfigProblem struct {
+	Kind    ConfigProblemKind
+	Line    int // line in config file, zero if unknown
+	Message string
+}
+
+func (p *ConfigProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Kind, p.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Kind, p.Message)
+}
+
+// configRule is a rule from config file with its position
+type configRule struct {
+	rule    *Rule
+	line    int
+	invalid bool // regular expression can't be compiled
+}
+
+// Result of config file validation. Rules keep order of config file
+type ConfigValidation struct {
+	Config   *FileConfig
+	Problems []*ConfigProblem
+
+	rules []*configRule
+}
+
+// Rules of config file in the same order as they are written. Rules with invalid patterns are skipped
+func (v *ConfigValidation) Rules() []*Rule {
+	rules := make([]*Rule, 0, len(v.rules))
+	for _, rule := range v.rules {
+		if !rule.invalid {
+			rules = append(rules, rule.rule)
+		}
+	}
+	return rules
+}
+
+// Adds problems for rules which don't match any entry of tree
+func (v *ConfigValidation) AddUnmatched(unmatched []*Rule) {
+	for _, rule := range unmatched {
+		for _, configRule := range v.rules {
+			if configRule.rule == rule {
+				v.Problems = append(v.Problems, &ConfigProblem{
+					Kind:    ProblemNeverMatches,
+					Line:    configRule.line,
+					Message: fmt.Sprintf("%s doesn't match any entry of checked directory", rule),
+				})
+			}
+		}
+	}
+}
+
+// Parses config file and checks it without looking at file system tree: unknown keys, invalid regular
+// expressions, duplicated rules and rules which are always overridden by exclude rules.
+// Returns error only if file can't be read or isn't a valid YAML document
+func ValidateFileConfig(fs FS, path string) (*ConfigValidation, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("fail parse %s: %v", path, err)
+	}
+
+	validation := &ConfigValidation{Config: &FileConfig{path: path}}
+	if len(doc.Content) == 0 {
+		return validation, nil // empty file
+	}
+	root := doc.Content[0]
+
+	validation.Problems = append(validation.Problems, unknownYAMLKeys(root, reflect.TypeOf(FileConfig{}), "")...)
+
+	// values of unknown keys are ignored, so document is decoded in usual way
+	if err := root.Decode(validation.Config); err != nil {
+		return nil, fmt.Errorf("fail parse %s: %v", path, err)
+	}
+
+	for _, section := range []struct {
+		key  string
+		kind RuleKind
+	}{{"exclude", RuleExclude}, {"include", RuleInclude}, {"ext", RuleExtension}} {
+		validation.rules = append(validation.rules, configRules(root, section.key, section.kind, "")...)
+	}
+	validation.rules = append(validation.rules, packagesConfigRules(root)...)
+
+	validation.Problems = append(validation.Problems, invalidPackagePatterns(root)...)
+	validation.Problems = append(validation.Problems, invalidPatterns(validation.rules)...)
+	validation.Problems = append(validation.Problems, duplicatedRules(validation.rules)...)
+	validation.Problems = append(validation.Problems, overlappingRules(validation.rules)...)
+	return validation, nil
+}
+
+// Returns names of fields decoded by yaml package from struct type
+func yamlFieldNames(structType reflect.Type) map[string]struct{} {
+	names := make(map[string]struct{})
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+// Checks keys of mapping nodes against fields of type, which node is decoded into
+func unknownYAMLKeys(node *yaml.Node, valueType reflect.Type, prefix string) []*ConfigProblem {
+	problems := make([]*ConfigProblem, 0)
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && valueType.Kind() == reflect.Struct:
+		fields := yamlFieldNames(valueType)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if _, known := fields[key.Value]; !known {
+				problems = append(problems, &ConfigProblem{Kind: ProblemUnknownKey, Line: key.Line, Message: fmt.Sprintf("%q isn't a known key", prefix+key.Value)})
+				continue
+			}
+
+			for j := 0; j < valueType.NumField(); j++ {
+				field := valueType.Field(j)
+				if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name == key.Value || (name == "" && strings.ToLower(field.Name) == key.Value) {
+					problems = append(problems, unknownYAMLKeys(value, field.Type, prefix+key.Value+".")...)
+				}
+			}
+		}
+	case node.Kind == yaml.MappingNode && valueType.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, unknownYAMLKeys(node.Content[i+1], valueType.Elem(), prefix+node.Content[i].Value+".")...)
+		}
+	case node.Kind == yaml.SequenceNode && valueType.Kind() == reflect.Slice:
+		for _, item := range node.Content {
+			problems = append(problems, unknownYAMLKeys(item, valueType.Elem(), prefix)...)
+		}
+	}
+	return problems
+}
+
+// Returns rules of list with lines where they are written. Package is set for rules of packages sections
+func configRules(root *yaml.Node, key string, kind RuleKind, pkg string) []*configRule {
+	rules := make([]*configRule, 0)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != key || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, item := range root.Content[i+1].Content {
+			rules = append(rules, &configRule{
+				rule: &Rule{Kind: kind, Pattern: item.Value, Source: SourceConfigFile, Package: pkg},
+				line: item.Line,
+			})
+		}
+	}
+	return rules
+}
+
+// Calls fn for every section of packages mapping
+func forEachPackageSection(root *yaml.Node, fn func(key, section *yaml.Node)) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "packages" || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+
+		packages := root.Content[i+1]
+		for j := 0; j+1 < len(packages.Content); j += 2 {
+			if packages.Content[j+1].Kind == yaml.MappingNode {
+				fn(packages.Content[j], packages.Content[j+1])
+			}
+		}
+	}
+}
+
+// Returns rules of packages sections in the order they are written
+func packagesConfigRules(root *yaml.Node) []*configRule {
+	rules := make([]*configRule, 0)
+	forEachPackageSection(root, func(key, section *yaml.Node) {
+		rules = append(rules, configRules(section, "exclude", RuleExclude, key.Value)...)
+		rules = append(rules, configRules(section, "include", RuleInclude, key.Value)...)
+		rules = append(rules, configRules(section, "ext", RuleExtension, key.Value)...)
+	})
+	return rules
+}
+
+// Package names of sections are checked as globs
+func invalidPackagePatterns(root *yaml.Node) []*ConfigProblem {
+	problems := make([]*ConfigProblem, 0)
+	forEachPackageSection(root, func(key, section *yaml.Node) {
+		if _, err := path.Match(key.Value, ""); err != nil {
+			problems = append(problems, &ConfigProblem{Kind: ProblemInvalidPattern, Line: key.Line, Message: fmt.Sprintf("package %q: %v", key.Value, err)})
+		}
+	})
+	return problems
+}
+
+func invalidPatterns(rules []*configRule) []*ConfigProblem {
+	problems := make([]*ConfigProblem, 0)
+	for _, rule := range rules {
+		if rule.rule.Kind == RuleExtension || !isStringPattern(rule.rule.Pattern) {
+			continue
+		}
+		if rule.rule.Package != "" && rule.rule.Kind == RuleExclude && rule.rule.Pattern == PackageKeepAll {
+			continue
+		}
+		if _, err := compileRegExpList([]string{rule.rule.Pattern}); err != nil {
+			rule.invalid = true
+			problems = append(problems, &ConfigProblem{Kind: ProblemInvalidPattern, Line: rule.line, Message: fmt.Sprintf("%s: %v", rule.rule, err)})
+		}
+	}
+	return problems
+}
+
+func duplicatedRules(rules []*configRule) []*ConfigProblem {
+	problems := make([]*ConfigProblem, 0)
+	for i, rule := range rules {
+		for _, previous := range rules[:i] {
+			if previous.rule.Kind == rule.rule.Kind && previous.rule.Pattern == rule.rule.Pattern && previous.rule.Package == rule.rule.Package {
+				problems = append(problems, &ConfigProblem{Kind: ProblemDuplicate, Line: rule.line, Message: fmt.Sprintf("%s is already written on line %d", rule.rule, previous.line)})
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// Finds include rules which never remove anything, because every name they match is excluded:
+// the same name is excluded or exclude regular expression matches include name. Rules of package
+// sections are compared with rules of the same section only
+func overlappingRules(rules []*configRule) []*ConfigProblem {
+	problems := make([]*ConfigProblem, 0)
+	for _, include := range rules {
+		if include.rule.Kind != RuleInclude {
+			continue
+		}
+
+		for _, exclude := range rules {
+			if exclude.rule.Kind != RuleExclude || exclude.rule.Package != include.rule.Package {
+				continue
+			}
+
+			overlaps := include.rule.Pattern == exclude.rule.Pattern
+			if !overlaps && !isStringPattern(include.rule.Pattern) && isStringPattern(exclude.rule.Pattern) {
+				if regExp, err := regexp.Compile(exclude.rule.Pattern); err == nil {
+					overlaps = regExp.MatchString(include.rule.Pattern)
+				}
+			}
+
+			if overlaps {
+				problems = append(problems, &ConfigProblem{
+					Kind:    ProblemOverlap,
+					Line:    include.line,
+					Message: fmt.Sprintf("%s never removes anything, it's overridden by exclude %q on line %d", include.rule, exclude.rule.Pattern, exclude.line),
+				})
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// Returns rules which don't match any entry of checking directory. Every rule is checked on its own,
+// so rules matching entries inside of removed or excluded directories are treated as matching
+func (sh *Shrinker) UnmatchedRules(ctx context.Context, rules []*Rule) []*Rule {
+	filters := make([]*Filter, len(rules))
+	for i, rule := range rules {
+		filters[i] = NewRulesFilter([]*Rule{rule})
+	}
+	matched := make([]bool, len(rules))
+
+	_ = sh.walker.Walk(sh.checkPath, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if osPathname == sh.checkPath {
+			return nil
+		}
+
+		for i, filter := range filters {
+			if !matched[i] && filter.MatchInPackage(packageNameFromPath(osPathname), de) != noMatch {
+				matched[i] = true
+			}
+		}
+		return nil
+	}, func(osPathname string, err error) ErrorAction {
+		if err != ctx.Err() {
+			sh.logger.Warn("skip entry", "path", osPathname, "error", err)
+		}
+		return SkipNode
+	})
+
+	unmatched := make([]*Rule, 0)
+	for i, rule := range rules {
+		if !matched[i] {
+			unmatched = append(unmatched, rule)
+		}
+	}
+	return unmatched
+}
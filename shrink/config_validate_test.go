@@ -0,0 +1,140 @@
+package shrink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFileConfigFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", fs.MemTree{
+		".node_shrinker.yml": fs.MemFile(`include:
+  - test
+  - docs
+  - "*.?!.**"
+  - test
+exclude:
+  - docs
+  - "^keep"
+  - ^keep
+inclde:
+  - examples
+ext:
+  - .ts
+budgets:
+  node_modules:
+    max_size: 100MB
+    maxfiles: 10
+`),
+		"empty.yml":    fs.MemFile(""),
+		"broken.yml":   fs.MemFile("include: [test"),
+		"skeleton.yml": fs.MemFile(string(ConfigSkeleton())),
+	})
+
+	validation, err := ValidateFileConfig(memFS, "/project/.node_shrinker.yml")
+	assert.Nil(t, err)
+
+	problems := make([]string, 0)
+	for _, problem := range validation.Problems {
+		problems = append(problems, problem.String())
+	}
+	assert.Equal(t, []string{
+		`line 10: unknown key: "inclde" isn't a known key`,
+		`line 17: unknown key: "budgets.node_modules.maxfiles" isn't a known key`,
+		"line 4: invalid pattern: include \"*.?!.**\" from config file: invalid regular expressions: error parsing regexp: missing argument to repetition operator: `*`",
+		`line 9: duplicated rule: exclude "^keep" from config file is already written on line 8`,
+		`line 5: duplicated rule: include "test" from config file is already written on line 2`,
+		`line 3: overlapping rules: include "docs" from config file never removes anything, it's overridden by exclude "docs" on line 7`,
+	}, problems)
+
+	assert.Equal(t, []string{"test", "docs", "*.?!.**", "test"}, validation.Config.Include)
+	assert.Equal(t, 7, len(validation.Rules()), "invalid pattern is skipped")
+
+	for _, path := range []string{"/project/empty.yml", "/project/skeleton.yml"} {
+		validation, err = ValidateFileConfig(memFS, path)
+		assert.Nil(t, err)
+		assert.Empty(t, validation.Problems, path)
+	}
+
+	_, err = ValidateFileConfig(memFS, "/project/broken.yml")
+	assert.NotNil(t, err)
+	_, err = ValidateFileConfig(memFS, "/project/missing.yml")
+	assert.NotNil(t, err)
+}
+
+func TestValidatePackagesConfigFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", fs.MemTree{
+		".node_shrinker.yml": fs.MemFile(`include:
+  - docs
+packages:
+  swagger-ui-dist:
+    exclude: ["*"]
+    exlude: [docs]
+  "@aws-sdk/*":
+    include: [docs, dist-es]
+    exclude: [docs]
+  "[broken":
+    include: [docs]
+`),
+	})
+
+	validation, err := ValidateFileConfig(memFS, "/project/.node_shrinker.yml")
+	assert.Nil(t, err)
+
+	problems := make([]string, 0)
+	for _, problem := range validation.Problems {
+		problems = append(problems, problem.String())
+	}
+	// rules of different sections aren't compared, exclude "*" isn't an invalid regular expression
+	assert.Equal(t, []string{
+		`line 6: unknown key: "packages.swagger-ui-dist.exlude" isn't a known key`,
+		`line 10: invalid pattern: package "[broken": syntax error in pattern`,
+		`line 8: overlapping rules: include "docs" in package "@aws-sdk/*" from config file never removes anything, it's overridden by exclude "docs" on line 9`,
+	}, problems)
+}
+
+func TestOverlappingRulesFunc(t *testing.T) {
+	rules := []*configRule{
+		{rule: &Rule{Kind: RuleInclude, Pattern: "test"}, line: 1},
+		{rule: &Rule{Kind: RuleInclude, Pattern: "^te"}, line: 2},
+		{rule: &Rule{Kind: RuleInclude, Pattern: "docs"}, line: 3},
+		{rule: &Rule{Kind: RuleExclude, Pattern: "^t.st$"}, line: 4},
+		{rule: &Rule{Kind: RuleExclude, Pattern: "tests"}, line: 5},
+	}
+
+	problems := overlappingRules(rules)
+	// include pattern matching excluded name is a usual way to keep some entries, it isn't reported
+	if assert.Equal(t, 1, len(problems)) {
+		assert.Equal(t, 1, problems[0].Line)
+	}
+}
+
+func TestUnmatchedRulesFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newMemTree())
+	sh := newMemShrinker(t, memFS, &Config{})
+
+	rules := []*Rule{
+		{Kind: RuleInclude, Pattern: "test"},
+		{Kind: RuleInclude, Pattern: "examples"},
+		{Kind: RuleExclude, Pattern: "^ke+p$"},
+		{Kind: RuleExtension, Pattern: ".coffee"},
+		{Kind: RuleExtension, Pattern: ".spec.js"},   // inside of removed directory
+		{Kind: RuleInclude, Pattern: "node_modules"}, // checking directory itself isn't matched
+	}
+	assert.Equal(t, []*Rule{rules[1], rules[3], rules[5]}, sh.UnmatchedRules(context.TODO(), rules))
+}
+
+func TestUnmatchedPackageRulesFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newMemTree())
+	sh := newMemShrinker(t, memFS, &Config{})
+
+	rules := []*Rule{
+		{Kind: RuleInclude, Pattern: "keep", Package: "b"},
+		{Kind: RuleInclude, Pattern: "keep", Package: "a"}, // there is no such entry in package a
+		{Kind: RuleExclude, Pattern: PackageKeepAll, Package: "?"},
+		{Kind: RuleExclude, Pattern: PackageKeepAll, Package: "c"},
+	}
+	assert.Equal(t, []*Rule{rules[1], rules[3]}, sh.UnmatchedRules(context.TODO(), rules))
+}
@@ -0,0 +1,273 @@
+package shrink
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+type LinkMode int
+
+const (
+	LinkAuto     LinkMode = iota // reflink where file system supports it, hardlink otherwise
+	LinkHardlink                 // always hardlink
+	LinkReflink                  // only reflink, files on file systems without reflinks support are skipped
+)
+
+var linkModeNames map[string]LinkMode = map[string]LinkMode{
+	"auto":     LinkAuto,
+	"hardlink": LinkHardlink,
+	"reflink":  LinkReflink,
+}
+
+var UnknownLinkModeError error = errors.New("unknown link mode")
+
+func ParseLinkMode(name string) (LinkMode, error) {
+	mode, exists := linkModeNames[name]
+	if !exists {
+		return LinkAuto, UnknownLinkModeError
+	}
+	return mode, nil
+}
+
+// dedupeFile is a file with all its hardlinks inside of checking directory
+type dedupeFile struct {
+	path  string   // the first of pathes in walking order
+	links []string // other pathes of the same inode
+	size  int64
+	mode  os.FileMode
+	hash  string
+}
+
+// Returns all pathes of file
+func (f *dedupeFile) pathes() []string {
+	return append([]string{f.path}, f.links...)
+}
+
+// identifies file on platforms with inodes, hardlinks have the same one
+type dedupeInode struct {
+	device uint64
+	inode  uint64
+}
+
+// files can be identical only with the same size. Mode is compared too, because all links share it
+type dedupeBucket struct {
+	size int64
+	mode os.FileMode
+}
+
+// Shows how much space can be reclaimed by linking identical files without changing anything
+func (sh *Shrinker) DedupeDryRun(ctx context.Context) (stats *FileStat) {
+	return sh.dedupe(ctx, true)
+}
+
+// Replaces byte-identical files with links to single copy
+func (sh *Shrinker) Dedupe(ctx context.Context) (stats *FileStat) {
+	return sh.dedupe(ctx, false)
+}
+
+func (sh *Shrinker) dedupe(ctx context.Context, dryRun bool) *FileStat {
+	candidates := make([]*dedupeFile, 0)
+	for _, bucket := range sh.collectDedupeBuckets(ctx) {
+		if len(bucket) > 1 {
+			candidates = append(candidates, bucket...)
+		}
+	}
+
+	sh.hashFiles(ctx, candidates)
+
+	linkedCh := sh.linkDuplicates(ctx, groupDuplicates(candidates), dryRun)
+	statsCh := sh.runStatGrabber(ctx, linkedCh)
+	return <-statsCh
+}
+
+// Walks through checking path and splits regular files into buckets by size and mode.
+// Hardlinks of the same inode are collected into one file, they don't take space twice
+func (sh *Shrinker) collectDedupeBuckets(ctx context.Context) map[dedupeBucket][]*dedupeFile {
+	buckets := make(map[dedupeBucket][]*dedupeFile)
+	inodes := make(map[dedupeInode]*dedupeFile)
+
+	_ = sh.walker.Walk(sh.checkPath, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if sh.match(ctx, osPathname, de).Excludes() {
+			return ExcludeError
+		}
+
+		if !de.IsRegular() {
+			return nil
+		}
+
+		stat, err := sh.fs.Stat(osPathname, false)
+		if err != nil {
+			sh.logger.Warn("fail stat", "path", osPathname, "error", err)
+			return nil
+		}
+
+		if stat.Size() == 0 {
+			return nil // nothing to reclaim
+		}
+
+		id := dedupeInode{device: stat.Device(), inode: stat.Inode()}
+		if file, exists := inodes[id]; exists && id.inode != 0 {
+			file.links = append(file.links, osPathname)
+			return nil
+		}
+
+		file := &dedupeFile{path: osPathname, size: stat.Size(), mode: stat.Mode()}
+		inodes[id] = file
+		bucket := dedupeBucket{size: stat.Size(), mode: stat.Mode()}
+		buckets[bucket] = append(buckets[bucket], file)
+		return nil
+	}, sh.fileFilterErrCallback)
+
+	return buckets
+}
+
+// Calculates content hashes of provided files in parallel. Files which can't be read are left without hash
+func (sh *Shrinker) hashFiles(ctx context.Context, files []*dedupeFile) {
+	filesCh := make(chan *dedupeFile)
+
+	var wg sync.WaitGroup
+	wg.Add(sh.concurentLimit)
+	for i := 0; i < sh.concurentLimit; i++ {
+		go func() {
+			defer wg.Done()
+
+			for file := range filesCh {
+				hash, err := sh.hashFile(file.path)
+				if err != nil {
+					sh.logger.Warn("fail hash", "path", file.path, "error", err)
+					continue
+				}
+				file.hash = hash
+			}
+		}()
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		filesCh <- file
+	}
+	close(filesCh)
+
+	wg.Wait()
+}
+
+func (sh *Shrinker) hashFile(path string) (string, error) {
+	file, err := sh.fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Groups files with identical content. Each group is sorted by path, so the first file is always the same one
+func groupDuplicates(files []*dedupeFile) [][]*dedupeFile {
+	type groupKey struct {
+		bucket dedupeBucket
+		hash   string
+	}
+
+	groupsByKey := make(map[groupKey][]*dedupeFile)
+	for _, file := range files {
+		if file.hash == "" {
+			continue
+		}
+		key := groupKey{bucket: dedupeBucket{size: file.size, mode: file.mode}, hash: file.hash}
+		groupsByKey[key] = append(groupsByKey[key], file)
+	}
+
+	groups := make([][]*dedupeFile, 0)
+	for _, group := range groupsByKey {
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].path < group[j].path
+		})
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0].path < groups[j][0].path
+	})
+	return groups
+}
+
+// Replaces every file in group with link to the first one. All hardlinks of duplicate are replaced, otherwise
+// its space isn't reclaimed. Reclaimed space is sent into returned channel once for every linked file,
+// files which already share data with the first one (e.g. linked by previous run) are skipped
+func (sh *Shrinker) linkDuplicates(ctx context.Context, groups [][]*dedupeFile, dryRun bool) chan *FileStat {
+	statsCh := make(chan *FileStat)
+
+	go func(out chan *FileStat) {
+		defer close(out)
+
+		for _, group := range groups {
+			original := group[0]
+			for _, duplicate := range group[1:] {
+				if ctx.Err() != nil {
+					return
+				}
+
+				if sh.fs.SameData(original.path, duplicate.path) {
+					continue // already linked, so doesn't take space
+				}
+
+				isLinked := true
+				for _, path := range duplicate.pathes() {
+					sh.logger.Debug("linking", "path", path, "original", original.path)
+					if dryRun {
+						continue
+					}
+					if err := sh.link(original.path, path); err != nil {
+						sh.logger.Warn("fail link", "path", path, "original", original.path, "error", err)
+						isLinked = false
+					}
+				}
+				if !isLinked {
+					continue // space of duplicate is still taken by its other links
+				}
+
+				out <- NewFileStat(duplicate.path, duplicate.path, duplicate.size, int64(len(duplicate.pathes())))
+			}
+		}
+	}(statsCh)
+
+	return statsCh
+}
+
+func (sh *Shrinker) link(src, dst string) error {
+	switch sh.linkMode {
+	case LinkHardlink:
+		return sh.fs.Hardlink(src, dst)
+	case LinkReflink:
+		return sh.fs.Reflink(src, dst)
+	}
+
+	err := sh.fs.Reflink(src, dst)
+	if err == ReflinkNotSupportedError {
+		return sh.fs.Hardlink(src, dst)
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package shrink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/icecream78/node_shrinker/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupDuplicatesFunc(t *testing.T) {
+	files := []*dedupeFile{
+		{path: "/b/x.js", size: 10, mode: 0644, hash: "h1"},
+		{path: "/a/x.js", size: 10, mode: 0644, hash: "h1"},
+		{path: "/c/x.js", size: 10, mode: 0755, hash: "h1"}, // another mode
+		{path: "/d/y.js", size: 10, mode: 0644, hash: "h2"}, // unique content
+		{path: "/e/x.js", size: 10, mode: 0644, hash: ""},   // failed hashing
+		{path: "/f/z.js", size: 20, mode: 0644, hash: "h3"},
+		{path: "/e/z.js", size: 20, mode: 0644, hash: "h3"},
+	}
+
+	groups := groupDuplicates(files)
+
+	pathes := make([][]string, 0)
+	for _, group := range groups {
+		groupPathes := make([]string, 0)
+		for _, file := range group {
+			groupPathes = append(groupPathes, file.path)
+		}
+		pathes = append(pathes, groupPathes)
+	}
+
+	assert.Equal(t, [][]string{{"/a/x.js", "/b/x.js"}, {"/e/z.js", "/f/z.js"}}, pathes)
+}
+
+func TestParseLinkModeFunc(t *testing.T) {
+	mode, err := ParseLinkMode("hardlink")
+	assert.Nil(t, err)
+	assert.Equal(t, LinkHardlink, mode)
+
+	_, err = ParseLinkMode("symlink")
+	assert.Equal(t, UnknownLinkModeError, err)
+}
+
+func TestLinkDuplicatesFunc(t *testing.T) {
+	testCases := []struct {
+		alias     string
+		mode      LinkMode
+		dryRun    bool
+		setupMock func(m *mocks.FS)
+		want      *fs.FileStat
+	}{
+		{
+			alias:  "Test auto mode fallbacks to hardlink",
+			mode:   LinkAuto,
+			dryRun: false,
+			setupMock: func(m *mocks.FS) {
+				m.On("SameData", "/a", "/b").Return(false)
+				m.On("SameData", "/a", "/c").Return(true)
+				m.On("Reflink", "/a", "/b").Return(fs.ReflinkNotSupportedError)
+				m.On("Hardlink", "/a", "/b").Return(nil)
+			},
+			want: fs.NewFileStat("result", "result", 10, 1),
+		},
+		{
+			alias:  "Test reflink mode skips unsupported files",
+			mode:   LinkReflink,
+			dryRun: false,
+			setupMock: func(m *mocks.FS) {
+				m.On("SameData", "/a", "/b").Return(false)
+				m.On("SameData", "/a", "/c").Return(false)
+				m.On("Reflink", "/a", "/b").Return(fs.ReflinkNotSupportedError)
+				m.On("Reflink", "/a", "/c").Return(nil)
+			},
+			want: fs.NewFileStat("result", "result", 10, 1),
+		},
+		{
+			alias:  "Test dry run doesn't link files",
+			mode:   LinkHardlink,
+			dryRun: true,
+			setupMock: func(m *mocks.FS) {
+				m.On("SameData", "/a", "/b").Return(false)
+				m.On("SameData", "/a", "/c").Return(false)
+			},
+			want: fs.NewFileStat("result", "result", 20, 2),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			osMock := new(mocks.FS)
+			tc.setupMock(osMock)
+
+			sh := &Shrinker{fs: osMock, linkMode: tc.mode, concurentLimit: 1, logger: loggerOrDefault(nil, false)}
+			groups := [][]*dedupeFile{{
+				{path: "/a", size: 10},
+				{path: "/b", size: 10},
+				{path: "/c", size: 10},
+			}}
+
+			ctx := context.TODO()
+			stats := <-sh.runStatGrabber(ctx, sh.linkDuplicates(ctx, groups, tc.dryRun))
+
+			assert.Equal(t, tc.want, stats)
+			osMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDedupeTwiceFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", fs.MemTree{
+		"node_modules/a/x.js": fs.MemFile("0123456789"),
+		"node_modules/b/x.js": fs.MemFile("0123456789"),
+		"node_modules/c/x.js": fs.MemFile("0123456789"),
+		"node_modules/a/y.js": fs.MemFile("abcde"),
+		"node_modules/b/y.js": fs.MemFile("abcde"),
+	})
+	// hardlinks of duplicate take space once and are replaced together
+	assert.Nil(t, memFS.Hardlink("/project/node_modules/b/y.js", "/project/node_modules/c/y.js"))
+
+	sh := newMemShrinker(t, memFS, &Config{LinkMode: LinkHardlink})
+	stats := sh.Dedupe(context.TODO())
+	assert.Equal(t, int64(2*10+5), stats.Size())
+	assert.Equal(t, int64(4), stats.FilesCount())
+	for _, path := range []string{"b/x.js", "c/x.js"} {
+		assert.True(t, memFS.SameFile("/project/node_modules/a/x.js", "/project/node_modules/"+path), path)
+	}
+	for _, path := range []string{"b/y.js", "c/y.js"} {
+		assert.True(t, memFS.SameFile("/project/node_modules/a/y.js", "/project/node_modules/"+path), path)
+	}
+
+	// everything is linked already
+	stats = sh.Dedupe(context.TODO())
+	assert.Equal(t, int64(0), stats.Size())
+	assert.Equal(t, int64(0), stats.FilesCount())
+	assert.Equal(t, int64(0), sh.DedupeDryRun(context.TODO()).Size())
+}
@@ -0,0 +1,149 @@
+package shrink
+
+import "sort"
+
+// PackageDiff shows change of single package between two snapshots
+type PackageDiff struct {
+	Name           string   `json:"name"`
+	VersionsBefore []string `json:"versions_before"`
+	VersionsAfter  []string `json:"versions_after"`
+	SizeBefore     int64    `json:"size_before"`
+	SizeAfter      int64    `json:"size_after"`
+	FilesBefore    int64    `json:"files_before"`
+	FilesAfter     int64    `json:"files_after"`
+}
+
+func (pd *PackageDiff) SizeDelta() int64 {
+	return pd.SizeAfter - pd.SizeBefore
+}
+
+func (pd *PackageDiff) FilesDelta() int64 {
+	return pd.FilesAfter - pd.FilesBefore
+}
+
+// SnapshotDiff is a result of comparing two snapshots
+type SnapshotDiff struct {
+	SizeBefore    int64              `json:"size_before"`
+	SizeAfter     int64              `json:"size_after"`
+	FilesBefore   int64              `json:"files_before"`
+	FilesAfter    int64              `json:"files_after"`
+	Added         []*PackageSnapshot `json:"added"`           // sorted by size from the biggest one
+	Removed       []*PackageSnapshot `json:"removed"`         // sorted by size from the biggest one
+	Changed       []*PackageDiff     `json:"changed"`         // sorted by absolute size change from the biggest one
+	NewLargeFiles []*FileSnapshot    `json:"new_large_files"` // sorted by size from the biggest one
+}
+
+func (d *SnapshotDiff) SizeDelta() int64 {
+	return d.SizeAfter - d.SizeBefore
+}
+
+func (d *SnapshotDiff) FilesDelta() int64 {
+	return d.FilesAfter - d.FilesBefore
+}
+
+// Compares two snapshots. Packages with the same versions and size are skipped.
+// Large files of after snapshot, which weren't large in before snapshot, are reported
+// if their size is not less than largeFileSize
+func DiffSnapshots(before, after *Snapshot, largeFileSize int64) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		SizeBefore:    before.Size,
+		SizeAfter:     after.Size,
+		FilesBefore:   before.FilesCount,
+		FilesAfter:    after.FilesCount,
+		Added:         make([]*PackageSnapshot, 0),
+		Removed:       make([]*PackageSnapshot, 0),
+		Changed:       make([]*PackageDiff, 0),
+		NewLargeFiles: make([]*FileSnapshot, 0),
+	}
+
+	beforePackages := make(map[string]*PackageSnapshot, len(before.Packages))
+	for _, pkg := range before.Packages {
+		beforePackages[pkg.Name] = pkg
+	}
+
+	for _, pkg := range after.Packages {
+		prev, exists := beforePackages[pkg.Name]
+		if !exists {
+			diff.Added = append(diff.Added, pkg)
+			continue
+		}
+		delete(beforePackages, pkg.Name)
+
+		if prev.Size == pkg.Size && prev.FilesCount == pkg.FilesCount && equalStrings(prev.Versions, pkg.Versions) {
+			continue
+		}
+
+		diff.Changed = append(diff.Changed, &PackageDiff{
+			Name:           pkg.Name,
+			VersionsBefore: prev.Versions,
+			VersionsAfter:  pkg.Versions,
+			SizeBefore:     prev.Size,
+			SizeAfter:      pkg.Size,
+			FilesBefore:    prev.FilesCount,
+			FilesAfter:     pkg.FilesCount,
+		})
+	}
+
+	for _, pkg := range before.Packages {
+		if _, exists := beforePackages[pkg.Name]; exists {
+			diff.Removed = append(diff.Removed, pkg)
+		}
+	}
+
+	beforeLargeFiles := make(map[string]struct{}, len(before.LargeFiles))
+	for _, file := range before.LargeFiles {
+		beforeLargeFiles[file.Path] = struct{}{}
+	}
+	for _, file := range after.LargeFiles {
+		if _, exists := beforeLargeFiles[file.Path]; exists || file.Size < largeFileSize {
+			continue
+		}
+		diff.NewLargeFiles = append(diff.NewLargeFiles, file)
+	}
+
+	sortPackageSnapshots(diff.Added)
+	sortPackageSnapshots(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		left, right := abs(diff.Changed[i].SizeDelta()), abs(diff.Changed[j].SizeDelta())
+		if left == right {
+			return diff.Changed[i].Name < diff.Changed[j].Name
+		}
+		return left > right
+	})
+	sort.Slice(diff.NewLargeFiles, func(i, j int) bool {
+		if diff.NewLargeFiles[i].Size == diff.NewLargeFiles[j].Size {
+			return diff.NewLargeFiles[i].Path < diff.NewLargeFiles[j].Path
+		}
+		return diff.NewLargeFiles[i].Size > diff.NewLargeFiles[j].Size
+	})
+
+	return diff
+}
+
+func sortPackageSnapshots(packages []*PackageSnapshot) {
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Size == packages[j].Size {
+			return packages[i].Name < packages[j].Name
+		}
+		return packages[i].Size > packages[j].Size
+	})
+}
+
+func equalStrings(left, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
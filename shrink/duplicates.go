@@ -0,0 +1,219 @@
+package shrink
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+// PackageInstance is a single installed copy of package
+type PackageInstance struct {
+	Name       string
+	Version    string
+	Path       string
+	Size       int64    // size without nested node_modules directory
+	Dependents []string // packages which depend on this copy, taken from lockfile
+}
+
+// DuplicatePackage is a package installed in several versions
+type DuplicatePackage struct {
+	Name      string
+	Instances []*PackageInstance
+}
+
+func (dp *DuplicatePackage) Size() (size int64) {
+	for _, instance := range dp.Instances {
+		size += instance.Size
+	}
+	return
+}
+
+func (dp *DuplicatePackage) VersionsCount() int {
+	versions := make(map[string]struct{})
+	for _, instance := range dp.Instances {
+		versions[instance.Version] = struct{}{}
+	}
+	return len(versions)
+}
+
+// Returns directory of project, which owns node_modules directory under checking path
+func (sh *Shrinker) projectPath() string {
+	return ProjectPath(sh.checkPath)
+}
+
+// Returns directory of project for checking path, which is either project itself or its node_modules directory
+func ProjectPath(checkPath string) string {
+	if filepath.Base(checkPath) == nodeModulesDirName {
+		return filepath.Dir(checkPath)
+	}
+	return checkPath
+}
+
+// Returns packages installed in several versions sorted by total size. Nothing is removed
+func (sh *Shrinker) DuplicatePackages(ctx context.Context) []*DuplicatePackage {
+	instances := sh.collectPackageInstances(ctx)
+
+	projectPath := sh.projectPath()
+	lock, err := sh.readLockfile(projectPath)
+	if err != nil {
+		sh.logger.Warn("fail read lockfile", "path", projectPath, "error", err)
+	} else {
+		dependents := lock.Dependents()
+		for _, instance := range instances {
+			key, err := filepath.Rel(projectPath, instance.Path)
+			if err != nil {
+				continue
+			}
+			instance.Dependents = dependents[filepath.ToSlash(key)]
+		}
+	}
+
+	return groupDuplicatePackages(instances)
+}
+
+func (sh *Shrinker) collectPackageInstances(ctx context.Context) []*PackageInstance {
+	instances := make([]*PackageInstance, 0)
+
+	_ = sh.walker.Walk(sh.checkPath, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !de.IsRegular() || de.Name() != PackageManifestName {
+			return nil
+		}
+
+		dir := filepath.Dir(osPathname)
+		if !isPackageRoot(dir) {
+			return nil
+		}
+
+		data, err := sh.fs.ReadFile(osPathname)
+		if err != nil {
+			sh.logger.Warn("fail read", "path", osPathname, "error", err)
+			return nil
+		}
+
+		manifest, err := parsePackageManifest(data)
+		if err != nil || manifest.Name == "" {
+			return nil
+		}
+
+		instances = append(instances, &PackageInstance{
+			Name:    manifest.Name,
+			Version: manifest.Version,
+			Path:    dir,
+			Size:    sh.packageSize(dir),
+		})
+		return nil
+	}, sh.fileFilterErrCallback)
+
+	return instances
+}
+
+// Returns size of package directory without nested node_modules, which contains other packages
+func (sh *Shrinker) packageSize(dir string) int64 {
+	stat, err := sh.fs.Stat(dir, true)
+	if err != nil {
+		return 0
+	}
+
+	size := stat.Size()
+	if nested, err := sh.fs.Stat(filepath.Join(dir, nodeModulesDirName), true); err == nil {
+		size -= nested.Size()
+	}
+	return size
+}
+
+// Checks is directory a root of installed package (node_modules/name or node_modules/@scope/name)
+func isPackageRoot(dir string) bool {
+	parent := filepath.Dir(dir)
+	if strings.HasPrefix(filepath.Base(parent), "@") {
+		parent = filepath.Dir(parent)
+	}
+	return filepath.Base(parent) == nodeModulesDirName
+}
+
+func groupDuplicatePackages(instances []*PackageInstance) []*DuplicatePackage {
+	byName := make(map[string]*DuplicatePackage)
+	for _, instance := range instances {
+		dp, exists := byName[instance.Name]
+		if !exists {
+			dp = &DuplicatePackage{Name: instance.Name}
+			byName[instance.Name] = dp
+		}
+		dp.Instances = append(dp.Instances, instance)
+	}
+
+	duplicates := make([]*DuplicatePackage, 0)
+	for _, dp := range byName {
+		if dp.VersionsCount() < 2 {
+			continue
+		}
+
+		sort.Slice(dp.Instances, func(i, j int) bool {
+			if cmp := compareVersions(dp.Instances[i].Version, dp.Instances[j].Version); cmp != 0 {
+				return cmp > 0
+			}
+			return dp.Instances[i].Path < dp.Instances[j].Path
+		})
+		duplicates = append(duplicates, dp)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Size() == duplicates[j].Size() {
+			return duplicates[i].Name < duplicates[j].Name
+		}
+		return duplicates[i].Size() > duplicates[j].Size()
+	})
+	return duplicates
+}
+
+// Compares versions by numeric parts (1.10.0 is greater than 1.9.0). Pre-release parts are compared as strings
+func compareVersions(left, right string) int {
+	leftParts := strings.FieldsFunc(left, isVersionSeparator)
+	rightParts := strings.FieldsFunc(right, isVersionSeparator)
+
+	for i := 0; i < len(leftParts) && i < len(rightParts); i++ {
+		leftNum, leftErr := strconv.Atoi(leftParts[i])
+		rightNum, rightErr := strconv.Atoi(rightParts[i])
+
+		switch {
+		case leftErr == nil && rightErr == nil:
+			if leftNum != rightNum {
+				if leftNum < rightNum {
+					return -1
+				}
+				return 1
+			}
+		case leftParts[i] != rightParts[i]:
+			if leftParts[i] < rightParts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// the longer version is greater, unless it's pre-release of the same version (1.0.0-beta < 1.0.0)
+	switch {
+	case len(leftParts) < len(rightParts):
+		if _, err := strconv.Atoi(rightParts[len(leftParts)]); err != nil {
+			return 1
+		}
+		return -1
+	case len(leftParts) > len(rightParts):
+		if _, err := strconv.Atoi(leftParts[len(rightParts)]); err != nil {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func isVersionSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '+'
+}
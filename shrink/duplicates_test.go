@@ -0,0 +1,77 @@
+package shrink
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersionsFunc(t *testing.T) {
+	testCases := []struct {
+		left  string
+		right string
+		want  int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.9.0", "1.10.0", -1},
+		{"2.0.0", "10.0.0", -1},
+		{"1.0.0-beta", "1.0.0", -1},
+		{"1.0.0", "1.0.0-beta", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0", "1.0.1", -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s vs %s", tc.left, tc.right), func(t *testing.T) {
+			assert.Equal(t, tc.want, compareVersions(tc.left, tc.right))
+		})
+	}
+}
+
+func TestIsPackageRootFunc(t *testing.T) {
+	testCases := []struct {
+		alias string
+		dir   string
+		want  bool
+	}{
+		{"Test regular package", "/p/node_modules/a", true},
+		{"Test scoped package", "/p/node_modules/@scope/a", true},
+		{"Test nested package", "/p/node_modules/a/node_modules/b", true},
+		{"Test directory inside package", "/p/node_modules/a/lib", false},
+		{"Test project directory", "/p", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, isPackageRoot(tc.dir), fmt.Sprintf("Input: %s", tc.dir))
+		})
+	}
+}
+
+func TestGroupDuplicatePackagesFunc(t *testing.T) {
+	instances := []*PackageInstance{
+		{Name: "a", Version: "1.0.0", Path: "/nm/a", Size: 10},
+		{Name: "a", Version: "1.10.0", Path: "/nm/x/node_modules/a", Size: 10},
+		{Name: "a", Version: "1.9.0", Path: "/nm/y/node_modules/a", Size: 10},
+		{Name: "b", Version: "1.0.0", Path: "/nm/b", Size: 100},
+		{Name: "b", Version: "1.0.0", Path: "/nm/x/node_modules/b", Size: 100}, // same version twice
+		{Name: "c", Version: "1.0.0", Path: "/nm/c", Size: 1},
+		{Name: "c", Version: "2.0.0", Path: "/nm/x/node_modules/c", Size: 100},
+	}
+
+	duplicates := groupDuplicatePackages(instances)
+
+	assert.Equal(t, 2, len(duplicates))
+	assert.Equal(t, "c", duplicates[0].Name)
+	assert.Equal(t, int64(101), duplicates[0].Size())
+	assert.Equal(t, "a", duplicates[1].Name)
+	assert.Equal(t, 3, duplicates[1].VersionsCount())
+
+	versions := make([]string, 0)
+	for _, instance := range duplicates[1].Instances {
+		versions = append(versions, instance.Version)
+	}
+	assert.Equal(t, []string{"1.10.0", "1.9.0", "1.0.0"}, versions)
+}
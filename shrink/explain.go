@@ -0,0 +1,106 @@
+package shrink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+var OutsidePathError error = errors.New("path is outside of checking directory")
+
+// ExplainStep is a filter decision about single entry on the way from checking directory to explained path
+type ExplainStep struct {
+	Path  string
+	Match *Match
+}
+
+// Explanation describes why path would or would not be removed
+type Explanation struct {
+	Path       string
+	Removed    bool
+	DecidedBy  string // path of entry, which match decided result. It's explained path itself or one of its parents
+	Match      *Match
+	Protection string // reason why path is kept, empty for removed pathes
+	Steps      []*ExplainStep
+}
+
+func (e *Explanation) String() string {
+	if e.Removed {
+		if e.DecidedBy == e.Path {
+			return fmt.Sprintf("%s would be removed: %s", e.Path, e.Match)
+		}
+		return fmt.Sprintf("%s would be removed together with %s: %s", e.Path, e.DecidedBy, e.Match)
+	}
+	return fmt.Sprintf("%s would be kept: %s", e.Path, e.Protection)
+}
+
+// Explains which rule decides fate of provided path. Every entry from checking directory
+// down to the path is checked the same way as Clean does it
+func (sh *Shrinker) Explain(path string) (*Explanation, error) {
+	relPath, err := filepath.Rel(sh.checkPath, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil, OutsidePathError
+	}
+
+	explanation := &Explanation{Path: path, Match: noMatch}
+
+	pathes := []string{sh.checkPath}
+	if relPath != "." {
+		current := sh.checkPath
+		for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+			current = filepath.Join(current, part)
+			pathes = append(pathes, current)
+		}
+	}
+
+	for _, entryPath := range pathes {
+		// walker of Clean doesn't follow symlinks, so they are matched as symlinks too
+		stat, err := sh.fs.Lstat(entryPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if protection := sh.protection(entryPath); protection != "" {
+			explanation.DecidedBy = entryPath
+			explanation.Protection = protection
+			return explanation, nil
+		}
+
+		de := NewFileInfoWithStat(filepath.Base(entryPath), stat.Mode(), stat.Size(), stat.ModTime())
+		match := sh.match(context.Background(), entryPath, de)
+		explanation.Steps = append(explanation.Steps, &ExplainStep{Path: entryPath, Match: match})
+
+		if match.Excludes() {
+			explanation.DecidedBy = entryPath
+			explanation.Match = match
+			if entryPath == path {
+				explanation.Protection = fmt.Sprintf("protected by %s", match)
+			} else {
+				explanation.Protection = fmt.Sprintf("parent directory %s is protected by %s", entryPath, match)
+			}
+			return explanation, nil
+		}
+
+		if match.Rejected != "" && entryPath == path {
+			explanation.DecidedBy = entryPath
+			explanation.Match = match
+			explanation.Protection = match.String()
+			return explanation, nil
+		}
+
+		if match.Removes() {
+			explanation.Removed = true
+			explanation.DecidedBy = entryPath
+			explanation.Match = match
+			return explanation, nil
+		}
+	}
+
+	explanation.DecidedBy = path
+	explanation.Protection = "no rule matches it"
+	return explanation, nil
+}
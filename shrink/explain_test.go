@@ -0,0 +1,72 @@
+package shrink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainFunc(t *testing.T) {
+	root, err := ioutil.TempDir("", "explain")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"a/test/inner", "a/keep/test", "b"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	for _, file := range []string{"a/test/inner/x.js", "a/index.ts", "b/index.js", "b/NOTICES", "b/cache.ts"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(root, file), []byte("data"), 0644))
+	}
+	assert.Nil(t, os.Symlink("index.js", filepath.Join(root, "b/link.ts")))
+
+	sh := &Shrinker{
+		fs:          fs.NewFS(),
+		checkPath:   root,
+		logger:      loggerOrDefault(nil, false),
+		noticesFile: filepath.Join(root, "b/NOTICES"),
+		cacheFile:   filepath.Join(root, "a/../b/cache.ts"),
+		filter: NewRulesFilter([]*Rule{
+			{Kind: RuleInclude, Pattern: "test", Source: SourceFlag},
+			{Kind: RuleInclude, Pattern: "NOT*", Source: SourceFlag},
+			{Kind: RuleExtension, Pattern: ".ts", Source: SourceDefault},
+			{Kind: RuleExclude, Pattern: "keep", Source: SourceConfigFile},
+		}),
+	}
+
+	testCases := []struct {
+		alias         string
+		path          string
+		wantRemoved   bool
+		wantDecidedBy string
+		wantMatch     MatchKind
+	}{
+		{"Test removed by name", "a/test", true, "a/test", MatchIncludeName},
+		{"Test removed with parent", "a/test/inner/x.js", true, "a/test", MatchIncludeName},
+		{"Test removed by extension", "a/index.ts", true, "a/index.ts", MatchExtension},
+		{"Test protected by parent exclude", "a/keep/test", false, "a/keep", MatchExcludeName},
+		{"Test not matched", "b/index.js", false, "b/index.js", MatchNone},
+		{"Test symlink isn't followed like in Clean", "b/link.ts", false, "b/link.ts", MatchNone},
+		{"Test notices file protection", "b/NOTICES", false, "b/NOTICES", MatchNone},
+		{"Test cache file protection", "b/cache.ts", false, "b/cache.ts", MatchNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			explanation, err := sh.Explain(filepath.Join(root, tc.path))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantRemoved, explanation.Removed)
+			assert.Equal(t, filepath.Join(root, tc.wantDecidedBy), explanation.DecidedBy)
+			assert.Equal(t, tc.wantMatch, explanation.Match.Kind)
+			if !tc.wantRemoved {
+				assert.NotEmpty(t, explanation.Protection)
+			}
+		})
+	}
+
+	_, err = sh.Explain(filepath.Dir(root))
+	assert.Equal(t, OutsidePathError, err)
+}
@@ -1,107 +1,355 @@
 package shrink
 
 import (
-	"path/filepath"
+	"errors"
+	"fmt"
+	"path"
 	"regexp"
 	"strings"
 
 	. "github.com/icecream78/node_shrinker/walker"
 )
 
+type compiledRule struct {
+	regExp *regexp.Regexp
+	rule   *Rule
+}
+
+// Pattern of package exclude rule, which keeps whole package untouched
+const PackageKeepAll = "*"
+
+// packageScope holds rules applied inside subtrees of packages matching pattern
+type packageScope struct {
+	pattern  string
+	filter   *Filter
+	override bool  // global rules aren't applied inside package
+	keepAll  *Rule // exclude rule with PackageKeepAll pattern
+}
+
+// Package patterns are globs of path.Match, dashes and dots of package names are plain characters
+func isPackageGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[\\")
+}
+
+func (s *packageScope) matches(pkg string) bool {
+	if s.pattern == pkg {
+		return true
+	}
+	matched, _ := path.Match(s.pattern, pkg)
+	return matched
+}
+
 type Filter struct {
-	includeFileNames   map[string]struct{}
-	shrunkFileExt      map[string]struct{}
-	excludeNames       map[string]struct{}
-	regExpIncludeNames []*regexp.Regexp
-	regExpExcludeNames []*regexp.Regexp
+	includeFileNames   map[string]*Rule
+	shrunkFileExt      map[string]*Rule
+	excludeNames       map[string]*Rule
+	regExpIncludeNames []*compiledRule
+	regExpExcludeNames []*compiledRule
+	rules              []*Rule
+	packages           []*packageScope // scopes of exact package names go before globs
+	invalid            []error         // rules with regular expressions which can't be compiled
 }
 
 func NewFilter(includeNames, excludeNames, includeExtenstions []string) *Filter {
-	patternInclude, regularInclude := devidePatternsFromRegularNames(includeNames)
-	patternExclude, regularExclude := devidePatternsFromRegularNames(excludeNames)
+	rules := NewRules(RuleInclude, SourceConfig, includeNames)
+	rules = append(rules, NewRules(RuleExclude, SourceConfig, excludeNames)...)
+	rules = append(rules, NewRules(RuleExtension, SourceConfig, includeExtenstions)...)
+	return NewRulesFilter(rules)
+}
+
+// Creates filter from rules. If the same pattern is provided several times, the first rule is used.
+// Rules of packages are grouped by package pattern and applied only inside subtrees of matching packages
+func NewRulesFilter(rules []*Rule) *Filter {
+	global := make([]*Rule, 0, len(rules))
+	packageRules := make(map[string][]*Rule)
+	patterns := make([]string, 0)
+	for _, rule := range rules {
+		if rule.Package == "" {
+			global = append(global, rule)
+			continue
+		}
+
+		if _, exists := packageRules[rule.Package]; !exists {
+			patterns = append(patterns, rule.Package)
+		}
+		packageRules[rule.Package] = append(packageRules[rule.Package], rule)
+	}
+
+	f := newNameFilter(global)
+	for _, pattern := range patterns {
+		f.scope(pattern).addRules(packageRules[pattern])
+	}
+	for _, scope := range f.packages {
+		f.rules = append(f.rules, scope.filter.rules...)
+		f.invalid = append(f.invalid, scope.filter.invalid...)
+		if scope.keepAll != nil {
+			f.rules = append(f.rules, scope.keepAll)
+		}
+	}
+	return f
+}
+
+// Returns scope of package pattern, new one is created if it doesn't exist
+func (f *Filter) scope(pattern string) *packageScope {
+	for _, scope := range f.packages {
+		if scope.pattern == pattern {
+			return scope
+		}
+	}
+
+	scope := &packageScope{pattern: pattern, filter: newNameFilter(nil)}
+	if isPackageGlob(pattern) {
+		f.packages = append(f.packages, scope)
+		return scope
+	}
+
+	// exact names are checked before globs, so the most specific rules are used
+	i := 0
+	for i < len(f.packages) && !isPackageGlob(f.packages[i].pattern) {
+		i++
+	}
+	f.packages = append(f.packages[:i], append([]*packageScope{scope}, f.packages[i:]...)...)
+	return scope
+}
+
+func (s *packageScope) addRules(rules []*Rule) {
+	for _, rule := range rules {
+		if rule.Kind != RuleExclude || rule.Pattern != PackageKeepAll {
+			s.filter.addFilterRule(rule)
+		} else if s.keepAll == nil {
+			s.keepAll = rule
+		}
+	}
+}
+
+// Global rules of filter aren't applied inside packages matching provided names or globs, only their own rules
+func (f *Filter) OverrideInPackages(patterns []string) {
+	for _, pattern := range patterns {
+		f.scope(pattern).override = true
+	}
+}
+
+// Returns package patterns which rules override global ones
+func (f *Filter) overridePackages() []string {
+	patterns := make([]string, 0)
+	for _, scope := range f.packages {
+		if scope.override {
+			patterns = append(patterns, scope.pattern)
+		}
+	}
+	return patterns
+}
+
+func newNameFilter(rules []*Rule) *Filter {
+	f := &Filter{
+		includeFileNames:   make(map[string]*Rule),
+		shrunkFileExt:      make(map[string]*Rule),
+		excludeNames:       make(map[string]*Rule),
+		regExpIncludeNames: make([]*compiledRule, 0),
+		regExpExcludeNames: make([]*compiledRule, 0),
+		rules:              make([]*Rule, 0, len(rules)),
+	}
+
+	for _, rule := range rules {
+		f.addFilterRule(rule)
+	}
+	return f
+}
+
+func (f *Filter) addFilterRule(rule *Rule) {
+	switch rule.Kind {
+	case RuleExtension:
+		f.addRule(f.shrunkFileExt, rule)
+	case RuleInclude:
+		f.regExpIncludeNames = f.addNameRule(f.includeFileNames, f.regExpIncludeNames, rule)
+	case RuleExclude:
+		f.regExpExcludeNames = f.addNameRule(f.excludeNames, f.regExpExcludeNames, rule)
+	}
+}
+
+func (f *Filter) addRule(rules map[string]*Rule, rule *Rule) {
+	if _, exists := rules[rule.Pattern]; !exists {
+		rules[rule.Pattern] = rule
+		f.rules = append(f.rules, rule)
+	}
+}
+
+func (f *Filter) addNameRule(names map[string]*Rule, regExps []*compiledRule, rule *Rule) []*compiledRule {
+	if !isStringPattern(rule.Pattern) {
+		f.addRule(names, rule)
+		return regExps
+	}
 
-	// TODO: figure out how handle incoming errors
-	compiledIncludeRegList, _ := compileRegExpList(patternInclude)
-	compiledExcludeRegList, _ := compileRegExpList(patternExclude)
+	for _, compiled := range regExps {
+		if compiled.rule.Pattern == rule.Pattern {
+			return regExps
+		}
+	}
 
-	return &Filter{
-		includeFileNames: sliceToMap(regularInclude),
-		shrunkFileExt:    sliceToMap(includeExtenstions),
+	// invalid rule is skipped, config validation reports it with position in config file
+	compiled, err := compileRegExpList([]string{rule.Pattern})
+	if err != nil {
+		f.invalid = append(f.invalid, fmt.Errorf("%s: %w", rule, err))
+	}
+	for _, regExp := range compiled {
+		regExps = append(regExps, &compiledRule{regExp: regExp, rule: rule})
+		f.rules = append(f.rules, rule)
+	}
+	return regExps
+}
 
-		excludeNames:       sliceToMap(regularExclude),
-		regExpIncludeNames: compiledIncludeRegList,
-		regExpExcludeNames: compiledExcludeRegList,
+// Returns error listing rules skipped because of invalid regular expressions, nil if all rules are used
+func (f *Filter) Err() error {
+	if len(f.invalid) == 0 {
+		return nil
 	}
+
+	messages := make([]string, 0, len(f.invalid))
+	for _, err := range f.invalid {
+		messages = append(messages, err.Error())
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// Returns all rules used by filter. Duplicated patterns and invalid regular expressions are skipped
+func (f *Filter) Rules() []*Rule {
+	return f.rules
 }
 
 // Checks is provided file need to removed or not
 func (f *Filter) Check(de FileInfoI) (bool, error) {
-	if f.isExcludeName(de.Name()) {
+	match := f.Match(de)
+	if match.Excludes() {
 		return false, ExcludeError
 	}
 
-	if f.isExcludeRegName(de.Name()) {
-		return false, ExcludeError
+	if match.Removes() {
+		return true, nil
 	}
 
-	if f.isIncludeName(de.Name()) {
-		return true, nil
+	return false, NotProcessError
+}
+
+// Returns rule which decides what to do with file owned by provided package (empty name for files outside
+// of node_modules). Rules of the first scope matching package extend global ones: exclude rules of both win,
+// then package rules are checked. Scope with override uses only own rules
+func (f *Filter) MatchInPackage(pkg string, de FileInfoI) *Match {
+	var scope *packageScope
+	if pkg != "" {
+		for _, candidate := range f.packages {
+			if candidate.matches(pkg) {
+				scope = candidate
+				break
+			}
+		}
+	}
+	if scope == nil {
+		return f.Match(de)
 	}
 
-	if f.isIncludeRegName(de.Name()) {
-		return true, nil
+	if scope.keepAll != nil {
+		return &Match{Kind: MatchExcludeName, Rule: scope.keepAll}
 	}
 
-	if de.IsRegular() && f.isIncludeExt(de.Name()) {
-		return true, nil
+	own := scope.filter.Match(de)
+	if own.Excludes() || scope.override {
+		return own
 	}
 
-	return false, NotProcessError
+	global := f.Match(de)
+	if global.Excludes() || own == noMatch {
+		return global
+	}
+	return own
+}
+
+// Returns rule of global rules which decides what to do with provided file. Exclude rules have priority over other ones
+func (f *Filter) Match(de FileInfoI) *Match {
+	if rule := f.matchExcludeName(de.Name()); rule != nil {
+		return &Match{Kind: MatchExcludeName, Rule: rule}
+	}
+
+	if rule := f.matchExcludeRegName(de.Name()); rule != nil {
+		return &Match{Kind: MatchExcludeRegExp, Rule: rule}
+	}
+
+	if rule := f.matchIncludeName(de.Name()); rule != nil {
+		return &Match{Kind: MatchIncludeName, Rule: rule}
+	}
+
+	if rule := f.matchIncludeRegName(de.Name()); rule != nil {
+		return &Match{Kind: MatchIncludeRegExp, Rule: rule}
+	}
+
+	if de.IsRegular() {
+		if rule := f.matchIncludeExt(de.Name()); rule != nil {
+			return &Match{Kind: MatchExtension, Rule: rule}
+		}
+	}
+
+	return noMatch
 }
 
 func (f *Filter) isExcludeName(name string) bool {
-	_, exists := f.excludeNames[name]
-	return exists
+	return f.matchExcludeName(name) != nil
+}
+
+func (f *Filter) matchExcludeName(name string) *Rule {
+	return f.excludeNames[name]
 }
 
 func (f *Filter) isExcludeRegName(name string) bool {
-	for _, pattern := range f.regExpExcludeNames {
-		matched := pattern.MatchString(name)
-		if matched {
-			return true
-		}
-	}
+	return f.matchExcludeRegName(name) != nil
+}
 
-	return false
+func (f *Filter) matchExcludeRegName(name string) *Rule {
+	return matchRegExpRules(f.regExpExcludeNames, name)
 }
 
 func (f *Filter) isIncludeRegName(name string) bool {
-	for _, pattern := range f.regExpIncludeNames {
-		matched := pattern.MatchString(name)
-		if matched {
-			return true
+	return f.matchIncludeRegName(name) != nil
+}
+
+func (f *Filter) matchIncludeRegName(name string) *Rule {
+	return matchRegExpRules(f.regExpIncludeNames, name)
+}
+
+func matchRegExpRules(rules []*compiledRule, name string) *Rule {
+	for _, compiled := range rules {
+		if compiled.regExp.MatchString(name) {
+			return compiled.rule
 		}
 	}
-	return false
+	return nil
 }
 
 func (f *Filter) isIncludeName(name string) bool {
-	_, exists := f.includeFileNames[name]
-	return exists
+	return f.matchIncludeName(name) != nil
 }
 
-func (f *Filter) isIncludeExt(name string) (exists bool) {
-	ext := filepath.Ext(name)
-	if ext == name { // for cases, when files starts with leading dot
-		return
-	}
+func (f *Filter) matchIncludeName(name string) *Rule {
+	return f.includeFileNames[name]
+}
 
-	if _, exists = f.shrunkFileExt[ext]; exists {
-		return
-	}
+func (f *Filter) isIncludeExt(name string) bool {
+	return f.matchIncludeExt(name) != nil
+}
+
+// Checks all extensions of name from the longest one, so ".d.ts" rule matches "index.d.ts".
+// Leading dot doesn't start extension (".eslintrc" has no extension) and trailing dot is not extension too
+func (f *Filter) matchIncludeExt(name string) *Rule {
+	for i := 1; i < len(name)-1; i++ {
+		if name[i] != '.' {
+			continue
+		}
 
-	if strings.HasPrefix(ext, ".") {
-		_, exists = f.shrunkFileExt[ext[1:]]
+		ext := name[i:]
+		if rule, exists := f.shrunkFileExt[ext]; exists {
+			return rule
+		}
+		if rule, exists := f.shrunkFileExt[ext[1:]]; exists {
+			return rule
+		}
 	}
-	return
+	return nil
 }
@@ -0,0 +1,206 @@
+//go:build go1.18
+// +build go1.18
+
+package shrink
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+// Fuzz targets run their seeds and corpus from testdata/fuzz with usual go test.
+// Inputs of found regressions are kept in corpus, new ones are added there by fuzzing, e.g.
+// go test ./shrink -run FuzzFilterMatch -fuzz FuzzFilterMatch -fuzztime 1m
+
+func FuzzFilterMatch(f *testing.F) {
+	for _, seed := range [][4]string{
+		{"index.js", "test", "keep", ".js"},
+		{"index.js", "test", "keep", "js"},
+		{".eslintrc", "", "", ".eslintrc"},
+		{"a.", "", "", ""},
+		{"test", "te.*", "^t", ""},
+		{"CHANGELOG.md", "*.md", "", ""},
+		{"x", "[", "(", "."},
+	} {
+		f.Add(seed[0], seed[1], seed[2], seed[3])
+	}
+
+	f.Fuzz(func(t *testing.T, name, include, exclude, ext string) {
+		de := NewFileInfoWithStat(name, 0644, 0, time.Time{})
+		match := NewFilter([]string{include}, []string{exclude}, []string{ext}).Match(de)
+
+		if NewFilter(nil, []string{exclude}, nil).Match(de).Excludes() && !match.Excludes() {
+			t.Errorf("exclude %q lost to other rules for %q: %v", exclude, name, match)
+		}
+
+		if NewFilter([]string{include}, []string{include}, nil).Match(de).Removes() {
+			t.Errorf("the same include and exclude %q removes %q", include, name)
+		}
+
+		trimmed := strings.TrimPrefix(ext, ".")
+		withDot := NewFilter(nil, nil, []string{"." + trimmed}).Match(de).Removes()
+		withoutDot := NewFilter(nil, nil, []string{trimmed}).Match(de).Removes()
+		if withDot != withoutDot {
+			t.Errorf("extension %q matches %q differently with and without leading dot", ext, name)
+		}
+
+		if withDot && !strings.HasSuffix(name, "."+trimmed) {
+			t.Errorf("extension %q matches %q without such suffix", ext, name)
+		}
+		if trimmed != "" && strings.HasSuffix(name, "."+trimmed) && !strings.HasPrefix(name, "."+trimmed) && !withDot {
+			t.Errorf("extension %q doesn't match %q", ext, name)
+		}
+	})
+}
+
+func FuzzDevidePatterns(f *testing.F) {
+	f.Add("test\n*.md\nnode_modules\n[a-z]+")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		names := strings.Split(input, "\n")
+		patterns, regular := devidePatternsFromRegularNames(names)
+
+		if len(patterns)+len(regular) != len(names) {
+			t.Fatalf("%d names divided into %d patterns and %d regular names", len(names), len(patterns), len(regular))
+		}
+		for _, pattern := range patterns {
+			if !isStringPattern(pattern) {
+				t.Errorf("%q isn't a pattern", pattern)
+			}
+		}
+		for _, name := range regular {
+			if isStringPattern(name) {
+				t.Errorf("%q is a pattern", name)
+			}
+		}
+
+		if compiled, _ := compileRegExpList(patterns); len(compiled) > len(patterns) {
+			t.Errorf("%d regular expressions compiled from %d patterns", len(compiled), len(patterns))
+		}
+	})
+}
+
+// Builds tree from slash separated pathes, one per line. Pathes ending with slash are directories.
+// Pathes conflicting with already added ones are skipped
+func fuzzTree(input string) fs.MemTree {
+	tree := fs.MemTree{"node_modules": fs.MemDir()}
+	for _, line := range strings.Split(input, "\n") {
+		parts := make([]string, 0)
+		for _, part := range strings.Split(line, "/") {
+			if part != "" && part != "." && part != ".." {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		path := "node_modules"
+		conflict := false
+		for i, part := range parts {
+			path += "/" + part
+			entry, exists := tree[path]
+			isLast := i == len(parts)-1
+			if exists && (!entry.IsDir || isLast) {
+				conflict = true
+				break
+			}
+			if !exists && !isLast {
+				tree[path] = fs.MemDir()
+			}
+		}
+		if conflict {
+			continue
+		}
+
+		if strings.HasSuffix(line, "/") {
+			tree[path] = fs.MemDir()
+		} else {
+			tree[path] = fs.MemFile(line)
+		}
+	}
+	return tree
+}
+
+type selectionObserver struct {
+	NopObserver
+
+	mu       sync.Mutex
+	selected []string
+}
+
+func (o *selectionObserver) OnMatch(event *MatchEvent) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.selected = append(o.selected, event.Path)
+	return true
+}
+
+func (o *selectionObserver) Selected() []string {
+	sort.Strings(o.selected)
+	return o.selected
+}
+
+func selectWithShrinker(t *testing.T, tree fs.MemTree, dryRun bool, include, exclude, ext string) []string {
+	memFS := fs.NewMemFS("/project", tree)
+	observer := &selectionObserver{}
+	sh, err := NewShrinker(&Config{
+		DryRun:         dryRun,
+		ConcurentLimit: 2,
+		CheckPath:      "/project/node_modules",
+		IncludeNames:   []string{include},
+		ExcludeNames:   []string{exclude},
+		RemoveFileExt:  []string{ext},
+		Observer:       observer,
+		Output:         ioutil.Discard, // dry run prints tree of checking directory
+		FS:             memFS,
+		Walker:         memFS,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dryRun {
+		sh.DryRun(context.TODO())
+	} else {
+		sh.Clean(context.TODO())
+		for _, path := range observer.Selected() {
+			if _, err := memFS.Stat(path, false); !os.IsNotExist(err) {
+				t.Errorf("%s isn't removed", path)
+			}
+		}
+	}
+	return observer.Selected()
+}
+
+func FuzzDryRunCleanSelection(f *testing.F) {
+	f.Add("a/test/x.js\na/index.ts\nkeep/test/y.js\nb/README.md", "test", "keep", ".ts")
+	f.Add("x/y/z.d.ts", "^[xy]$", "z", "d.ts")
+
+	f.Fuzz(func(t *testing.T, pathes, include, exclude, ext string) {
+		tree := fuzzTree(pathes)
+
+		dryRun := selectWithShrinker(t, tree, true, include, exclude, ext)
+		clean := selectWithShrinker(t, tree, false, include, exclude, ext)
+
+		if strings.Join(dryRun, "\n") != strings.Join(clean, "\n") {
+			t.Errorf("dry run selected %v, but clean selected %v", dryRun, clean)
+		}
+		for _, path := range clean {
+			if filepath.Clean(path) == "/project/node_modules" {
+				t.Errorf("checking directory itself is selected")
+			}
+		}
+	})
+}
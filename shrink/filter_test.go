@@ -2,7 +2,9 @@ package shrink
 
 import (
 	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	. "github.com/icecream78/node_shrinker/walker"
 	"github.com/stretchr/testify/assert"
@@ -181,6 +183,18 @@ func (s *fileTestStub) IsRegular() bool {
 	return s.isRegular
 }
 
+func (s *fileTestStub) Size() int64 {
+	return 0
+}
+
+func (s *fileTestStub) Mode() os.FileMode {
+	return 0
+}
+
+func (s *fileTestStub) ModTime() time.Time {
+	return time.Time{}
+}
+
 func TestCheckFunc(t *testing.T) {
 	includes := []string{
 		"file1",
@@ -219,3 +233,100 @@ func TestCheckFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchFunc(t *testing.T) {
+	filter := NewRulesFilter([]*Rule{
+		{Kind: RuleInclude, Pattern: "file1", Source: SourceFlag},
+		{Kind: RuleInclude, Pattern: "nam*", Source: SourceConfigFile},
+		{Kind: RuleExtension, Pattern: ".js", Source: SourceDefault},
+		{Kind: RuleExclude, Pattern: "file2", Source: SourceFlag},
+		{Kind: RuleExclude, Pattern: "sur*", Source: SourceConfig},
+		{Kind: RuleInclude, Pattern: "file1", Source: SourceDefault}, // duplicate pattern
+	})
+
+	testCases := []struct {
+		alias      string
+		input      *fileTestStub
+		wantKind   MatchKind
+		wantSource RuleSource
+	}{
+		{alias: "Include regular file name", input: newFileTestStub("file1", true), wantKind: MatchIncludeName, wantSource: SourceFlag},
+		{alias: "Include regexp file name", input: newFileTestStub("name.txt", true), wantKind: MatchIncludeRegExp, wantSource: SourceConfigFile},
+		{alias: "Include extension file name", input: newFileTestStub("script.js", true), wantKind: MatchExtension, wantSource: SourceDefault},
+		{alias: "Exclude regular file name", input: newFileTestStub("file2", true), wantKind: MatchExcludeName, wantSource: SourceFlag},
+		{alias: "Exclude regexp file name", input: newFileTestStub("surname.js", true), wantKind: MatchExcludeRegExp, wantSource: SourceConfig},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			match := filter.Match(tc.input)
+			assert.Equal(t, tc.wantKind, match.Kind)
+			assert.Equal(t, tc.wantSource, match.Rule.Source)
+		})
+	}
+
+	assert.Equal(t, noMatch, filter.Match(newFileTestStub("scripts.js", false)))
+}
+
+func TestMatchInPackageFunc(t *testing.T) {
+	rules := []*Rule{
+		{Kind: RuleInclude, Pattern: "README.md", Source: SourceFlag},
+		{Kind: RuleExtension, Pattern: ".ts", Source: SourceFlag},
+		{Kind: RuleExclude, Pattern: "LICENSE", Source: SourceFlag},
+	}
+	rules = append(rules, NewPackageRules("swagger-ui-dist", RuleExclude, SourceConfigFile, []string{PackageKeepAll})...)
+	rules = append(rules, NewPackageRules("@aws-sdk/*", RuleInclude, SourceConfigFile, []string{"dist-es"})...)
+	rules = append(rules, NewPackageRules("@aws-sdk/client-s3", RuleExclude, SourceConfigFile, []string{"README.md"})...)
+	rules = append(rules, NewPackageRules("moment", RuleInclude, SourceConfigFile, []string{"locale"})...)
+	rules = append(rules, NewPackageRules("moment", RuleExclude, SourceConfigFile, []string{"README.md"})...)
+	filter := NewRulesFilter(rules)
+	filter.OverrideInPackages([]string{"moment"})
+
+	testCases := []struct {
+		alias       string
+		pkg         string
+		input       *fileTestStub
+		wantKind    MatchKind
+		wantPattern string
+	}{
+		{alias: "Global rule outside of packages", pkg: "", input: newFileTestStub("README.md", true), wantKind: MatchIncludeName, wantPattern: "README.md"},
+		{alias: "Global rule in package without section", pkg: "lodash", input: newFileTestStub("README.md", true), wantKind: MatchIncludeName, wantPattern: "README.md"},
+		{alias: "Package rule isn't applied in other packages", pkg: "lodash", input: newFileTestStub("dist-es", false)},
+		{alias: "Whole package is kept", pkg: "swagger-ui-dist", input: newFileTestStub("index.js", true), wantKind: MatchExcludeName, wantPattern: PackageKeepAll},
+		{alias: "Glob section extends global rules", pkg: "@aws-sdk/util-utf8", input: newFileTestStub("dist-es", false), wantKind: MatchIncludeRegExp, wantPattern: "dist-es"},
+		{alias: "Global rule in glob section", pkg: "@aws-sdk/util-utf8", input: newFileTestStub("index.d.ts", true), wantKind: MatchExtension, wantPattern: ".ts"},
+		{alias: "Exact name section goes before glob", pkg: "@aws-sdk/client-s3", input: newFileTestStub("README.md", true), wantKind: MatchExcludeName, wantPattern: "README.md"},
+		{alias: "Global exclude wins over package include", pkg: "@aws-sdk/util-utf8", input: newFileTestStub("LICENSE", true), wantKind: MatchExcludeName, wantPattern: "LICENSE"},
+		{alias: "Override section rule", pkg: "moment", input: newFileTestStub("locale", false), wantKind: MatchIncludeName, wantPattern: "locale"},
+		{alias: "Global rule isn't applied in override section", pkg: "moment", input: newFileTestStub("moment.d.ts", true)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			match := filter.MatchInPackage(tc.pkg, tc.input)
+			if tc.wantPattern == "" {
+				assert.Equal(t, noMatch, match)
+				return
+			}
+			assert.Equal(t, tc.wantKind, match.Kind)
+			assert.Equal(t, tc.wantPattern, match.Rule.Pattern)
+		})
+	}
+
+	assert.Equal(t, []string{"moment"}, filter.overridePackages())
+	assert.Equal(t, len(rules), len(filter.Rules()))
+}
+
+func TestFilterErrFunc(t *testing.T) {
+	assert.Nil(t, NewFilter([]string{"test", "^te"}, nil, nil).Err())
+
+	filter := NewRulesFilter(append(
+		NewRules(RuleInclude, SourceFlag, []string{"*.?!.**", "test"}),
+		NewPackageRules("a", RuleExclude, SourceConfigFile, []string{"[keep"})...,
+	))
+	assert.Equal(t, 1, len(filter.Rules()), "invalid rules are skipped")
+	if assert.NotNil(t, filter.Err()) {
+		assert.Contains(t, filter.Err().Error(), `include "*.?!.**" from flag`)
+		assert.Contains(t, filter.Err().Error(), `exclude "[keep" in package "a" from config file`)
+	}
+}
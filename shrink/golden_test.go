@@ -0,0 +1,282 @@
+package shrink
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+	"github.com/stretchr/testify/assert"
+)
+
+// go test ./shrink -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files of end-to-end tests")
+
+const (
+	goldenDir       = "testdata/golden"
+	goldenRoot      = "/project"
+	goldenCheckPath = "/project/node_modules"
+	fixtureIndent   = "  "
+)
+
+// Parses compact description of tree. Every line is an entry, nesting is set by two spaces indent:
+//
+//	node_modules/                  directory
+//	  a/
+//	    index.js 120               file with size in bytes or human readable format (1.5KB)
+//	    empty.js                   empty file
+//	  .bin/
+//	    a -> ../a/index.js         symlink
+//
+// Lines starting with # are comments
+func parseFixture(data string) (fs.MemTree, error) {
+	tree := make(fs.MemTree)
+	dirs := make([]string, 0)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(trimmed)
+		if indent%len(fixtureIndent) != 0 || indent/len(fixtureIndent) > len(dirs) {
+			return nil, fmt.Errorf("line %d: wrong indent", lineNumber)
+		}
+		dirs = dirs[:indent/len(fixtureIndent)]
+
+		name, entry, err := parseFixtureEntry(strings.TrimSpace(trimmed))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+
+		path := strings.Join(append(dirs, name), "/")
+		if _, exists := tree[path]; exists {
+			return nil, fmt.Errorf("line %d: duplicate entry %s", lineNumber, path)
+		}
+		tree[path] = entry
+
+		if entry.IsDir {
+			dirs = append(dirs, name)
+		}
+	}
+	return tree, scanner.Err()
+}
+
+func parseFixtureEntry(line string) (string, fs.MemEntry, error) {
+	if parts := strings.SplitN(line, " -> ", 2); len(parts) == 2 {
+		return parts[0], fs.MemSymlink(parts[1]), nil
+	}
+
+	if strings.HasSuffix(line, "/") {
+		return strings.TrimSuffix(line, "/"), fs.MemDir(), nil
+	}
+
+	parts := strings.Fields(line)
+	switch len(parts) {
+	case 1:
+		return parts[0], fs.MemFile(""), nil
+	case 2:
+		size, err := ParseByteSize(parts[1])
+		if err != nil {
+			return "", fs.MemEntry{}, fmt.Errorf("invalid size %q", parts[1])
+		}
+		return parts[0], fs.MemFile(strings.Repeat("x", int(size))), nil
+	}
+	return "", fs.MemEntry{}, fmt.Errorf("unexpected entry %q", line)
+}
+
+// Renders tree in the same format as parseFixture reads it
+func renderFixture(memFS *fs.MemFS, root string) string {
+	var sb strings.Builder
+	_ = memFS.Walk(root, func(osPathname string, de FileInfoI) error {
+		if osPathname == root {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(root, osPathname)
+		sb.WriteString(strings.Repeat(fixtureIndent, strings.Count(filepath.ToSlash(relPath), "/")))
+
+		switch {
+		case de.IsDir():
+			fmt.Fprintf(&sb, "%s/\n", de.Name())
+		case de.Mode()&os.ModeSymlink != 0:
+			target, _ := memFS.Readlink(osPathname)
+			fmt.Fprintf(&sb, "%s -> %s\n", de.Name(), target)
+		case de.Size() == 0:
+			fmt.Fprintf(&sb, "%s\n", de.Name())
+		default:
+			fmt.Fprintf(&sb, "%s %d\n", de.Name(), de.Size())
+		}
+		return nil
+	}, func(string, error) ErrorAction {
+		return SkipNode
+	})
+	return sb.String()
+}
+
+// Collects events with pathes relative to project root, cleaners send them concurrently
+type goldenObserver struct {
+	NopObserver
+
+	mu      sync.Mutex
+	matched []string
+	removed []string
+}
+
+func goldenPath(path string) string {
+	relPath, _ := filepath.Rel(goldenRoot, path)
+	return filepath.ToSlash(relPath)
+}
+
+func (o *goldenObserver) OnMatch(event *MatchEvent) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.matched = append(o.matched, fmt.Sprintf("%s: %v", goldenPath(event.Path), event.Match))
+	return true
+}
+
+func (o *goldenObserver) OnRemove(event *RemoveEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.removed = append(o.removed, fmt.Sprintf("%s: %v (%d bytes, %d files)", goldenPath(event.Path), event.Match, event.Size, event.FilesCount))
+}
+
+func goldenReport(title string, lines []string, stats *fs.FileStat) string {
+	sort.Strings(lines)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", title)
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&sb, "\n# total\nsize: %d\nfiles: %d\n", stats.Size(), stats.FilesCount())
+	return sb.String()
+}
+
+// Rules of case are read from config.yml, default rules are used if there is no such file.
+// Also returns packages which sections override global rules
+func goldenRules(t *testing.T, dir string) ([]*Rule, []string) {
+	path := filepath.Join(dir, "config.yml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		rules := NewRules(RuleInclude, SourceDefault, DefaultRemoveDirNames)
+		return append(rules, NewRules(RuleExtension, SourceDefault, DefaultRemoveFileExt)...), nil
+	}
+
+	cfg, err := LoadFileConfig(path)
+	if !assert.Nil(t, err) {
+		return nil, nil
+	}
+	rules := NewRules(RuleExclude, SourceConfigFile, cfg.Exclude)
+	rules = append(rules, NewRules(RuleInclude, SourceConfigFile, cfg.Include)...)
+	rules = append(rules, NewRules(RuleExtension, SourceConfigFile, cfg.Ext)...)
+	return append(rules, cfg.PackagesRules()...), cfg.OverridePackages()
+}
+
+func runGolden(t *testing.T, dir string, dryRun bool) string {
+	fixture, err := ioutil.ReadFile(filepath.Join(dir, "tree.txt"))
+	if !assert.Nil(t, err) {
+		return ""
+	}
+	tree, err := parseFixture(string(fixture))
+	if !assert.Nil(t, err) {
+		return ""
+	}
+
+	memFS := fs.NewMemFS(goldenRoot, tree)
+	before := renderFixture(memFS, goldenRoot)
+	observer := &goldenObserver{}
+	rules, overridePackages := goldenRules(t, dir)
+
+	sh, err := NewShrinker(&Config{
+		DryRun:           dryRun,
+		ConcurentLimit:   4,
+		CheckPath:        goldenCheckPath,
+		Rules:            rules,
+		OverridePackages: overridePackages,
+		Observer:         observer,
+		Output:           ioutil.Discard, // dry run prints tree of checking directory, it's useless here
+		FS:               memFS,
+		Walker:           memFS,
+	})
+	if !assert.Nil(t, err) {
+		return ""
+	}
+
+	if dryRun {
+		stats := sh.DryRun(context.TODO())
+		assert.Equal(t, before, renderFixture(memFS, goldenRoot), "dry run changed tree")
+		return goldenReport("matched", observer.matched, stats)
+	}
+
+	stats := sh.Clean(context.TODO())
+	return goldenReport("removed", observer.removed, stats) + "\n# tree\n" + renderFixture(memFS, goldenRoot)
+}
+
+func checkGolden(t *testing.T, path, got string) {
+	if *updateGolden {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(got), 0644))
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if assert.Nil(t, err, "run tests with -update to create golden file") {
+		assert.Equal(t, string(want), got)
+	}
+}
+
+func TestGoldenFunc(t *testing.T) {
+	cases, err := ioutil.ReadDir(goldenDir)
+	assert.Nil(t, err)
+
+	for _, tc := range cases {
+		if !tc.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(goldenDir, tc.Name())
+		t.Run(tc.Name(), func(t *testing.T) {
+			checkGolden(t, filepath.Join(dir, "dryrun.golden"), runGolden(t, dir, true))
+			checkGolden(t, filepath.Join(dir, "clean.golden"), runGolden(t, dir, false))
+		})
+	}
+}
+
+func TestParseFixtureFunc(t *testing.T) {
+	tree, err := parseFixture(`
+# comment
+node_modules/
+  a/
+    index.js 2KB
+    empty.js
+  .bin/
+    a -> ../a/index.js
+package.json 10
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, fs.MemTree{
+		"node_modules":            fs.MemDir(),
+		"node_modules/a":          fs.MemDir(),
+		"node_modules/a/index.js": fs.MemFile(strings.Repeat("x", 2000)),
+		"node_modules/a/empty.js": fs.MemFile(""),
+		"node_modules/.bin":       fs.MemDir(),
+		"node_modules/.bin/a":     fs.MemSymlink("../a/index.js"),
+		"package.json":            fs.MemFile(strings.Repeat("x", 10)),
+	}, tree)
+
+	_, err = parseFixture("a/\n      b.js")
+	assert.NotNil(t, err)
+	_, err = parseFixture("a.js 1 2")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,266 @@
+package shrink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// npm runs this script of project after every install
+const HookScriptName = "postinstall"
+
+const (
+	hookCommandName  = "node_shrinker"
+	hookScriptsField = "scripts"
+	hookOperator     = "&&"
+)
+
+// command of script with operator which chains it to the previous one: &&, || or ;
+type scriptCommand struct {
+	operator string
+	command  string
+}
+
+var (
+	HookExistsError   error = errors.New("postinstall script already runs node_shrinker")
+	HookNotFoundError error = errors.New("postinstall script doesn't run node_shrinker")
+	HookChainError    error = errors.New("removing node_shrinker chained with || or ; changes meaning of postinstall script, edit it manually")
+)
+
+// Returns command of postinstall script. npm runs it from project directory, so config file
+// is found there by default, otherwise it's passed relative to project directory
+func HookCommand(configFile string) string {
+	command := hookCommandName + " --node"
+	if configFile != "" {
+		command += " --config " + quoteShellArg(configFile)
+	}
+	return command
+}
+
+// Quotes argument for shell which runs npm scripts. Double quotes are used, because both sh and cmd.exe
+// understand them. Arguments without special characters are kept as is
+func quoteShellArg(arg string) string {
+	isSafe := arg != ""
+	for _, r := range arg {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./@+:,=", r)) {
+			isSafe = false
+			break
+		}
+	}
+	if isSafe {
+		return arg
+	}
+
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range arg {
+		if strings.ContainsRune("\\\"$`", r) {
+			quoted.WriteByte('\\')
+		}
+		quoted.WriteRune(r)
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// Splits script into commands chained with &&, || and ;. Operators inside quotes or escaped with backslash
+// aren't split. Pipes, background & and subshells aren't handled, such command is kept as a whole
+func splitScript(script string) []scriptCommand {
+	commands := make([]scriptCommand, 0)
+	operator := ""
+	start := 0
+	add := func(end int) {
+		if command := strings.TrimSpace(script[start:end]); command != "" {
+			commands = append(commands, scriptCommand{operator: operator, command: command})
+		}
+	}
+
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\\':
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';' || strings.HasPrefix(script[i:], "&&") || strings.HasPrefix(script[i:], "||"):
+			add(i)
+			width := 2
+			if c == ';' {
+				width = 1
+			}
+			operator = script[i : i+width]
+			i += width - 1
+			start = i + 1
+		}
+	}
+	add(len(script))
+	return commands
+}
+
+// Joins commands with their operators. Operator of the first command is dropped
+func joinScript(commands []scriptCommand) string {
+	var script strings.Builder
+	for i, command := range commands {
+		if i != 0 {
+			if command.operator == ";" {
+				script.WriteString("; ")
+			} else {
+				script.WriteString(" " + command.operator + " ")
+			}
+		}
+		script.WriteString(command.command)
+	}
+	return script.String()
+}
+
+// Checks does command run node_shrinker: by name, by path (e.g. ./node_modules/.bin/node_shrinker),
+// through npx or with environment variables set before it
+func isHookCommand(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) != 0 && fields[0] == "env" {
+		fields = fields[1:]
+	}
+	for len(fields) != 0 && isEnvAssignment(fields[0]) {
+		fields = fields[1:]
+	}
+	if len(fields) != 0 && fields[0] == "npx" {
+		fields = fields[1:]
+		for len(fields) != 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return false
+	}
+
+	name := path.Base(strings.ReplaceAll(strings.Trim(fields[0], `"'`), "\\", "/"))
+	return strings.TrimSuffix(name, ".cmd") == hookCommandName
+}
+
+// Checks is field a variable assignment like NODE_ENV=production
+func isEnvAssignment(field string) bool {
+	i := strings.IndexByte(field, '=')
+	if i <= 0 {
+		return false
+	}
+	for j, r := range field[:i] {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || j != 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns scripts object and postinstall script of package.json, nil object if there is no scripts field
+func parseHookScripts(data []byte) (root, scripts *jsonObject, script *jsonMember, err error) {
+	if root, err = parseJSONDocument(data); err != nil {
+		return nil, nil, nil, err
+	}
+
+	_, scriptsMember := root.member(hookScriptsField)
+	if scriptsMember == nil {
+		return root, nil, nil, nil
+	}
+	if scripts, err = parseJSONObject(data, scriptsMember.valueStart); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s field isn't an object", hookScriptsField)
+	}
+
+	_, script = scripts.member(HookScriptName)
+	if script != nil && data[script.valueStart] != '"' {
+		return nil, nil, nil, fmt.Errorf("%s script isn't a string", HookScriptName)
+	}
+	return root, scripts, script, nil
+}
+
+// Adds command to postinstall script of package.json. Command is chained after existing script,
+// scripts field is created if it's missing. Order of keys and formatting are kept.
+// Returns HookExistsError if script already runs node_shrinker
+func AddHook(data []byte, command string) ([]byte, error) {
+	root, scripts, script, err := parseHookScripts(data)
+	if err != nil {
+		return nil, err
+	}
+	style := detectJSONStyle(data, root)
+
+	if scripts == nil {
+		value := style.object(1, HookScriptName, encodeJSONString(command))
+		return insertJSONMember(data, root, 0, style, hookScriptsField, value), nil
+	}
+
+	if script == nil {
+		return insertJSONMember(data, scripts, 1, style, HookScriptName, encodeJSONString(command)), nil
+	}
+
+	var current string
+	if err := json.Unmarshal(data[script.valueStart:script.valueEnd], &current); err != nil {
+		return nil, err
+	}
+	commands := splitScript(current)
+	for _, existing := range commands {
+		if isHookCommand(existing.command) {
+			return nil, HookExistsError
+		}
+	}
+
+	value := joinScript(append(commands, scriptCommand{operator: hookOperator, command: command}))
+	return replaceRange(data, script.valueStart, script.valueEnd, encodeJSONString(value)), nil
+}
+
+// Removes node_shrinker commands from postinstall script of package.json. Script without other commands
+// is removed, as well as scripts field which becomes empty. Returns HookNotFoundError if there is nothing to remove.
+// Command is removed only if it's chained with && to the next one, so the next one takes its operator
+// and runs under the same condition, or with && to the previous one if it's the last. Otherwise HookChainError is returned
+func RemoveHook(data []byte) ([]byte, error) {
+	root, scripts, script, err := parseHookScripts(data)
+	if err != nil {
+		return nil, err
+	}
+	if script == nil {
+		return nil, HookNotFoundError
+	}
+
+	var current string
+	if err := json.Unmarshal(data[script.valueStart:script.valueEnd], &current); err != nil {
+		return nil, err
+	}
+	commands := splitScript(current)
+	kept := make([]scriptCommand, 0, len(commands))
+	for i, command := range commands {
+		if !isHookCommand(command.command) {
+			kept = append(kept, command)
+			continue
+		}
+
+		if i+1 < len(commands) {
+			if commands[i+1].operator != hookOperator {
+				return nil, HookChainError
+			}
+			commands[i+1].operator = command.operator
+		} else if command.operator != "" && command.operator != hookOperator {
+			return nil, HookChainError
+		}
+	}
+	if len(kept) == len(commands) {
+		return nil, HookNotFoundError
+	}
+
+	if len(kept) != 0 {
+		return replaceRange(data, script.valueStart, script.valueEnd, encodeJSONString(joinScript(kept))), nil
+	}
+
+	if len(scripts.members) == 1 {
+		i, _ := root.member(hookScriptsField)
+		return removeJSONMember(data, root, i), nil
+	}
+	i, _ := scripts.member(HookScriptName)
+	return removeJSONMember(data, scripts, i), nil
+}
@@ -0,0 +1,242 @@
+package shrink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAddHookFunc(t *testing.T) {
+	command := HookCommand("")
+
+	testCases := []struct {
+		alias   string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			"Test no scripts with two spaces",
+			"{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n",
+			"{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"scripts\": {\n    \"postinstall\": \"node_shrinker --node\"\n  }\n}\n",
+			nil,
+		},
+		{
+			"Test no scripts with tabs and CRLF",
+			"{\r\n\t\"name\": \"app\"\r\n}",
+			"{\r\n\t\"name\": \"app\",\r\n\t\"scripts\": {\r\n\t\t\"postinstall\": \"node_shrinker --node\"\r\n\t}\r\n}",
+			nil,
+		},
+		{
+			"Test compact document",
+			`{"name":"app","scripts":{"test":"jest"}}`,
+			`{"name":"app","scripts":{"test":"jest","postinstall":"node_shrinker --node"}}`,
+			nil,
+		},
+		{
+			"Test scripts in the middle keep order",
+			"{\n    \"name\": \"app\",\n    \"scripts\": {\n        \"test\": \"jest\"\n    },\n    \"dependencies\": {}\n}\n",
+			"{\n    \"name\": \"app\",\n    \"scripts\": {\n        \"test\": \"jest\",\n        \"postinstall\": \"node_shrinker --node\"\n    },\n    \"dependencies\": {}\n}\n",
+			nil,
+		},
+		{
+			"Test empty scripts",
+			"{\n  \"scripts\": {}\n}\n",
+			"{\n  \"scripts\": {\n    \"postinstall\": \"node_shrinker --node\"\n  }\n}\n",
+			nil,
+		},
+		{
+			"Test existing postinstall is chained",
+			"{\n  \"scripts\": {\n    \"postinstall\": \"patch-package\"\n  }\n}\n",
+			"{\n  \"scripts\": {\n    \"postinstall\": \"patch-package && node_shrinker --node\"\n  }\n}\n",
+			nil,
+		},
+		{
+			"Test existing hook",
+			`{"scripts": {"postinstall": "patch-package && node_shrinker -c ci.yml"}}`,
+			"",
+			HookExistsError,
+		},
+		{
+			"Test existing hook after other operators",
+			`{"scripts": {"postinstall": "patch-package || true; NODE_ENV=ci npx --no-install node_shrinker"}}`,
+			"",
+			HookExistsError,
+		},
+		{
+			"Test operators inside of quotes",
+			`{"scripts": {"postinstall": "echo 'a && node_shrinker'"}}`,
+			`{"scripts": {"postinstall": "echo 'a && node_shrinker' && node_shrinker --node"}}`,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			got, err := AddHook([]byte(tc.input), command)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+
+	for _, input := range []string{`{"name": }`, `[]`, `{"scripts": []}`, `{"scripts": {"postinstall": 1}}`} {
+		_, err := AddHook([]byte(input), command)
+		assert.NotNil(t, err, input)
+	}
+}
+
+func TestRemoveHookFunc(t *testing.T) {
+	testCases := []struct {
+		alias   string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			"Test other scripts are kept",
+			"{\n  \"scripts\": {\n    \"postinstall\": \"node_shrinker --node\",\n    \"test\": \"jest\"\n  }\n}\n",
+			"{\n  \"scripts\": {\n    \"test\": \"jest\"\n  }\n}\n",
+			nil,
+		},
+		{
+			"Test chained commands are kept",
+			`{"scripts": {"postinstall": "patch-package && node_shrinker --node && echo done"}}`,
+			`{"scripts": {"postinstall": "patch-package && echo done"}}`,
+			nil,
+		},
+		{
+			"Test previous operator is kept",
+			`{"scripts": {"postinstall": "patch-package || true; ./node_modules/.bin/node_shrinker --node && echo done"}}`,
+			`{"scripts": {"postinstall": "patch-package || true; echo done"}}`,
+			nil,
+		},
+		{
+			"Test the first command",
+			`{"scripts": {"postinstall": "CI=1 node_shrinker --node && echo done"}}`,
+			`{"scripts": {"postinstall": "echo done"}}`,
+			nil,
+		},
+		{
+			"Test the last command",
+			`{"scripts": {"postinstall": "patch-package && npx node_shrinker"}}`,
+			`{"scripts": {"postinstall": "patch-package"}}`,
+			nil,
+		},
+		{
+			"Test several commands",
+			`{"scripts": {"postinstall": "node_shrinker --node && node_shrinker --node"}}`,
+			`{}`,
+			nil,
+		},
+		{
+			"Test || after command",
+			`{"scripts": {"postinstall": "CI=1 node_shrinker --node || echo failed"}}`,
+			"",
+			HookChainError,
+		},
+		{
+			"Test ; after command",
+			`{"scripts": {"postinstall": "patch-package || true; ./node_modules/.bin/node_shrinker --node; echo done"}}`,
+			"",
+			HookChainError,
+		},
+		{
+			"Test || before the last command",
+			`{"scripts": {"postinstall": "patch-package || node_shrinker --node"}}`,
+			"",
+			HookChainError,
+		},
+		{
+			"Test ; before the last command",
+			`{"scripts": {"postinstall": "patch-package; node_shrinker --node"}}`,
+			"",
+			HookChainError,
+		},
+		{
+			"Test no hook",
+			`{"scripts": {"postinstall": "patch-package"}}`,
+			"",
+			HookNotFoundError,
+		},
+		{
+			"Test no scripts",
+			`{"name": "app"}`,
+			"",
+			HookNotFoundError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			got, err := RemoveHook([]byte(tc.input))
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestHookCommandFunc(t *testing.T) {
+	testCases := []struct {
+		configFile string
+		want       string
+	}{
+		{"", "node_shrinker --node"},
+		{"config/shrink.yml", "node_shrinker --node --config config/shrink.yml"},
+		{"my config/shrink.yml", `node_shrinker --node --config "my config/shrink.yml"`},
+		{"$HOME/`x`\\\"y\".yml", "node_shrinker --node --config \"\\$HOME/\\`x\\`\\\\\\\"y\\\".yml\""},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, HookCommand(tc.configFile), tc.configFile)
+	}
+}
+
+func TestIsHookCommandFunc(t *testing.T) {
+	testCases := []struct {
+		command string
+		want    bool
+	}{
+		{"node_shrinker --node", true},
+		{"npx node_shrinker", true},
+		{"npx --yes node_shrinker --node", true},
+		{"./node_modules/.bin/node_shrinker --node", true},
+		{`.\node_modules\.bin\node_shrinker.cmd`, true},
+		{"NODE_ENV=production DEBUG= node_shrinker", true},
+		{"env CI=1 node_shrinker", true},
+		{"echo node_shrinker", false},
+		{"node_shrinker-fork", false},
+		{"1A=x node_shrinker", false},
+		{"CI=1", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, isHookCommand(tc.command), tc.command)
+	}
+}
+
+// Removing just added hook restores document byte by byte
+func TestHookRoundTripFunc(t *testing.T) {
+	for _, input := range []string{
+		"{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n",
+		"{\r\n\t\"name\": \"app\",\r\n\t\"scripts\": {\r\n\t\t\"build\": \"tsc\"\r\n\t}\r\n}\r\n",
+		`{"name":"app","scripts":{"postinstall":"patch-package"},"dependencies":{"a":"^1.0.0"}}`,
+		`{"scripts": {"postinstall": "patch-package", "test": "jest"}}`,
+		"{}",
+	} {
+		added, err := AddHook([]byte(input), HookCommand("config/shrink.yml"))
+		assert.Nil(t, err, input)
+
+		removed, err := RemoveHook(added)
+		assert.Nil(t, err, input)
+		assert.Equal(t, input, string(removed))
+	}
+}
+
+func TestConfigSkeletonFunc(t *testing.T) {
+	cfg := FileConfig{}
+	assert.Nil(t, yaml.Unmarshal(ConfigSkeleton(), &cfg))
+	assert.Equal(t, DefaultRemoveDirNames, cfg.Include)
+	assert.Equal(t, DefaultRemoveFileExt, cfg.Ext)
+	assert.Empty(t, cfg.Exclude)
+}
@@ -0,0 +1,212 @@
+package shrink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Editing of JSON documents in place: members are inserted and removed by offsets in source,
+// so order of keys and formatting of untouched parts are kept as is
+
+var InvalidJSONError error = errors.New("invalid JSON document")
+
+type jsonMember struct {
+	key        string
+	keyStart   int // offset of opening quote of key
+	keyEnd     int // offset after closing quote of key
+	valueStart int
+	valueEnd   int // offset after value
+}
+
+type jsonObject struct {
+	start   int // offset of opening brace
+	end     int // offset of closing brace
+	members []*jsonMember
+}
+
+func (obj *jsonObject) member(key string) (int, *jsonMember) {
+	for i, member := range obj.members {
+		if member.key == key {
+			return i, member
+		}
+	}
+	return -1, nil
+}
+
+// jsonStyle is formatting of document, which is used for inserted members
+type jsonStyle struct {
+	newline   string // empty for single line documents
+	indent    string // one level of indentation
+	separator string // between key and value, e.g. ": "
+}
+
+func skipJSONSpaces(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\r' || data[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func skipJSONString(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
+
+func skipJSONValue(data []byte, i int) int {
+	switch data[i] {
+	case '"':
+		return skipJSONString(data, i)
+	case '{', '[':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '"':
+				i = skipJSONString(data, i)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	}
+
+	for i < len(data) && !strings.ContainsRune(",}] \t\r\n", rune(data[i])) {
+		i++
+	}
+	return i
+}
+
+// Parses members of object starting at provided offset. Document must be valid JSON
+func parseJSONObject(data []byte, start int) (*jsonObject, error) {
+	i := skipJSONSpaces(data, start)
+	if i >= len(data) || data[i] != '{' {
+		return nil, fmt.Errorf("%w: object expected at offset %d", InvalidJSONError, i)
+	}
+
+	obj := &jsonObject{start: i}
+	for i = skipJSONSpaces(data, i+1); data[i] != '}'; {
+		member := &jsonMember{keyStart: i, keyEnd: skipJSONString(data, i)}
+		if err := json.Unmarshal(data[member.keyStart:member.keyEnd], &member.key); err != nil {
+			return nil, fmt.Errorf("%w: %v", InvalidJSONError, err)
+		}
+
+		member.valueStart = skipJSONSpaces(data, skipJSONSpaces(data, member.keyEnd)+1) // skip colon
+		member.valueEnd = skipJSONValue(data, member.valueStart)
+		obj.members = append(obj.members, member)
+
+		i = skipJSONSpaces(data, member.valueEnd)
+		if data[i] == ',' {
+			i = skipJSONSpaces(data, i+1)
+		}
+	}
+	obj.end = i
+	return obj, nil
+}
+
+func parseJSONDocument(data []byte) (*jsonObject, error) {
+	if !json.Valid(data) {
+		return nil, InvalidJSONError
+	}
+	return parseJSONObject(data, 0)
+}
+
+// Returns whitespace placed before key of member
+func (obj *jsonObject) leading(data []byte, i int) string {
+	from := obj.start + 1
+	if i > 0 {
+		from = obj.members[i-1].valueEnd
+	}
+
+	space := string(data[from:obj.members[i].keyStart])
+	if comma := strings.LastIndex(space, ","); comma != -1 {
+		space = space[comma+1:]
+	}
+	return space
+}
+
+// Detects formatting from the first member of root object. Empty document is formatted with two spaces
+func detectJSONStyle(data []byte, root *jsonObject) jsonStyle {
+	if len(root.members) == 0 {
+		return jsonStyle{newline: "\n", indent: "  ", separator: ": "}
+	}
+
+	style := jsonStyle{separator: string(data[root.members[0].keyEnd:root.members[0].valueStart])}
+	leading := root.leading(data, 0)
+	if newline := strings.LastIndex(leading, "\n"); newline != -1 {
+		style.newline = "\n"
+		if strings.Contains(leading, "\r\n") {
+			style.newline = "\r\n"
+		}
+		style.indent = leading[newline+1:]
+	}
+	return style
+}
+
+// Encodes string without escaping of HTML characters, so shell commands like "a && b" stay readable
+func encodeJSONString(value string) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// Formats object with one member placed at provided depth, e.g. {"postinstall": "..."} for scripts field
+func (style jsonStyle) object(depth int, key string, rawValue []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{" + style.newline + strings.Repeat(style.indent, depth+1))
+	buf.Write(encodeJSONString(key))
+	buf.WriteString(style.separator)
+	buf.Write(rawValue)
+	buf.WriteString(style.newline + strings.Repeat(style.indent, depth) + "}")
+	return buf.Bytes()
+}
+
+func replaceRange(data []byte, start, end int, insert []byte) []byte {
+	result := make([]byte, 0, len(data)-(end-start)+len(insert))
+	result = append(result, data[:start]...)
+	result = append(result, insert...)
+	return append(result, data[end:]...)
+}
+
+// Adds member after the last one of object, placed at provided depth. Formatting of the last member is repeated
+func insertJSONMember(data []byte, obj *jsonObject, depth int, style jsonStyle, key string, rawValue []byte) []byte {
+	if len(obj.members) == 0 {
+		return replaceRange(data, obj.start, obj.end+1, style.object(depth, key, rawValue))
+	}
+
+	last := obj.members[len(obj.members)-1]
+	var buf bytes.Buffer
+	buf.WriteString("," + obj.leading(data, len(obj.members)-1))
+	buf.Write(encodeJSONString(key))
+	buf.Write(data[last.keyEnd:last.valueStart])
+	buf.Write(rawValue)
+	return replaceRange(data, last.valueEnd, last.valueEnd, buf.Bytes())
+}
+
+// Removes member with separating comma and whitespace before it. The only member leaves empty object
+func removeJSONMember(data []byte, obj *jsonObject, i int) []byte {
+	switch {
+	case len(obj.members) == 1:
+		return replaceRange(data, obj.start, obj.end+1, []byte("{}"))
+	case i == 0:
+		return replaceRange(data, obj.members[0].keyStart, obj.members[1].keyStart, nil)
+	}
+	return replaceRange(data, obj.members[i-1].valueEnd, obj.members[i].valueEnd, nil)
+}
@@ -0,0 +1,165 @@
+package shrink
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const rootDependentName = "(root)"
+
+// lockfile names in order of priority. Hidden lockfile is written by npm v7+ inside node_modules
+var lockfileNames []string = []string{
+	"npm-shrinkwrap.json",
+	"package-lock.json",
+	"node_modules/.package-lock.json",
+}
+
+type lockPackage struct {
+	Name         string
+	Version      string
+	Dependencies []string
+}
+
+// lockfile contains installed packages keyed by path relative to project root (e.g. node_modules/a/node_modules/b)
+type lockfile struct {
+	packages map[string]*lockPackage
+}
+
+type rawLockV2Package struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+type rawLockV1Dependency struct {
+	Version      string                          `json:"version"`
+	Requires     map[string]string               `json:"requires"`
+	Dependencies map[string]*rawLockV1Dependency `json:"dependencies"`
+}
+
+type rawLockfile struct {
+	LockfileVersion int                             `json:"lockfileVersion"`
+	Packages        map[string]*rawLockV2Package    `json:"packages"`
+	Dependencies    map[string]*rawLockV1Dependency `json:"dependencies"`
+}
+
+// Reads first found lockfile of project
+func (sh *Shrinker) readLockfile(projectPath string) (*lockfile, error) {
+	var lastErr error
+	for _, name := range lockfileNames {
+		data, err := sh.fs.ReadFile(filepath.Join(projectPath, filepath.FromSlash(name)))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return parseLockfile(data)
+	}
+	return nil, lastErr
+}
+
+// Parses npm lockfile. Supports "packages" section of lockfile v2/v3 and "dependencies" section of lockfile v1
+func parseLockfile(data []byte) (*lockfile, error) {
+	raw := rawLockfile{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	lf := &lockfile{packages: make(map[string]*lockPackage)}
+	if len(raw.Packages) != 0 {
+		for key, pkg := range raw.Packages {
+			name := pkg.Name
+			if name == "" {
+				name = packageNameFromPath(key)
+			}
+
+			deps := mergeDependencyNames(pkg.Dependencies, pkg.OptionalDependencies, pkg.PeerDependencies)
+			if key == "" {
+				deps = mergeDependencyNames(pkg.Dependencies, pkg.OptionalDependencies, pkg.PeerDependencies, pkg.DevDependencies)
+			}
+			lf.packages[key] = &lockPackage{Name: name, Version: pkg.Version, Dependencies: deps}
+		}
+		return lf, nil
+	}
+
+	lf.addV1Dependencies("", raw.Dependencies)
+	root := &lockPackage{Name: rootDependentName}
+	for name := range raw.Dependencies {
+		root.Dependencies = append(root.Dependencies, name) // v1 doesn't store root requirements, so every top level package is counted
+	}
+	lf.packages[""] = root
+	return lf, nil
+}
+
+func (lf *lockfile) addV1Dependencies(parentKey string, deps map[string]*rawLockV1Dependency) {
+	for name, dep := range deps {
+		key := path.Join(parentKey, nodeModulesDirName, name)
+		lf.packages[key] = &lockPackage{
+			Name:         name,
+			Version:      dep.Version,
+			Dependencies: mergeDependencyNames(dep.Requires),
+		}
+		lf.addV1Dependencies(key, dep.Dependencies)
+	}
+}
+
+func mergeDependencyNames(deps ...map[string]string) []string {
+	names := make(map[string]struct{})
+	for _, dependencies := range deps {
+		for name := range dependencies {
+			names[name] = struct{}{}
+		}
+	}
+	return sortedSet(names)
+}
+
+// Resolves dependency name the same way as node.js does: from closest node_modules directory up to project root
+func (lf *lockfile) resolve(fromKey, name string) string {
+	dir := fromKey
+	for {
+		candidate := path.Join(dir, nodeModulesDirName, name)
+		if _, exists := lf.packages[candidate]; exists {
+			return candidate
+		}
+
+		if dir == "" {
+			return ""
+		}
+
+		idx := strings.LastIndex(dir, nodeModulesDirName+"/")
+		if idx <= 0 {
+			dir = ""
+		} else {
+			dir = strings.TrimSuffix(dir[:idx], "/")
+		}
+	}
+}
+
+// Returns dependents of every installed package keyed by its path. Dependents are in name@version format
+func (lf *lockfile) Dependents() map[string][]string {
+	dependents := make(map[string]map[string]struct{})
+	for key, pkg := range lf.packages {
+		dependentName := rootDependentName
+		if key != "" {
+			dependentName = pkg.Name + "@" + pkg.Version
+		}
+
+		for _, dep := range pkg.Dependencies {
+			resolved := lf.resolve(key, dep)
+			if resolved == "" {
+				continue
+			}
+			addToSet(dependents, resolved, dependentName)
+		}
+	}
+
+	result := make(map[string][]string, len(dependents))
+	for key, set := range dependents {
+		result[key] = sortedSet(set)
+	}
+	return result
+}
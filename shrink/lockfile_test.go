@@ -0,0 +1,85 @@
+package shrink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockfileV2DependentsFunc(t *testing.T) {
+	data := []byte(`{
+		"lockfileVersion": 2,
+		"packages": {
+			"": {"name": "project", "dependencies": {"lodash": "^4.0.0", "foo": "^1.0.0"}, "devDependencies": {"@scope/bar": "^2.0.0"}},
+			"node_modules/lodash": {"version": "4.17.21"},
+			"node_modules/foo": {"version": "1.0.0", "dependencies": {"lodash": "^3.0.0", "@scope/bar": "^2.0.0"}},
+			"node_modules/foo/node_modules/lodash": {"version": "3.10.1"},
+			"node_modules/@scope/bar": {"version": "2.1.0", "dependencies": {"lodash": "^4.0.0", "missing": "^1.0.0"}}
+		}
+	}`)
+
+	lock, err := parseLockfile(data)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"node_modules/lodash":                  {"(root)", "@scope/bar@2.1.0"},
+		"node_modules/foo":                     {"(root)"},
+		"node_modules/foo/node_modules/lodash": {"foo@1.0.0"},
+		"node_modules/@scope/bar":              {"(root)", "foo@1.0.0"},
+	}, lock.Dependents())
+}
+
+func TestLockfileV1DependentsFunc(t *testing.T) {
+	data := []byte(`{
+		"lockfileVersion": 1,
+		"dependencies": {
+			"lodash": {"version": "4.17.21"},
+			"foo": {
+				"version": "1.0.0",
+				"requires": {"lodash": "^3.0.0"},
+				"dependencies": {
+					"lodash": {"version": "3.10.1"}
+				}
+			}
+		}
+	}`)
+
+	lock, err := parseLockfile(data)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"node_modules/lodash":                  {"(root)"},
+		"node_modules/foo":                     {"(root)"},
+		"node_modules/foo/node_modules/lodash": {"foo@1.0.0"},
+	}, lock.Dependents())
+}
+
+func TestLockfileResolveFunc(t *testing.T) {
+	lock := &lockfile{packages: map[string]*lockPackage{
+		"":                              {},
+		"node_modules/a":                {},
+		"node_modules/b":                {},
+		"node_modules/b/node_modules/a": {},
+		"node_modules/b/node_modules/c": {},
+		"node_modules/b/node_modules/c/node_modules/d": {},
+	}}
+
+	testCases := []struct {
+		alias string
+		from  string
+		name  string
+		want  string
+	}{
+		{"Test resolve from root", "", "a", "node_modules/a"},
+		{"Test resolve nested copy", "node_modules/b", "a", "node_modules/b/node_modules/a"},
+		{"Test resolve from parent node_modules", "node_modules/b/node_modules/c", "a", "node_modules/b/node_modules/a"},
+		{"Test resolve from root node_modules", "node_modules/b/node_modules/c/node_modules/d", "b", "node_modules/b"},
+		{"Test missing package", "node_modules/b", "x", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, lock.resolve(tc.from, tc.name))
+		})
+	}
+}
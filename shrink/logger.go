@@ -1,6 +1,179 @@
 package shrink
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota // removed and kept entries
+	LevelInfo               // results of additional steps, e.g. written notices file
+	LevelWarn               // entries which were skipped because of errors
+	LevelError              // errors which break the whole run
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+var UnknownLevelError error = errors.New("unknown log level")
+
+func ParseLevel(value string) (Level, error) {
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		if strings.EqualFold(value, level.String()) {
+			return level, nil
+		}
+	}
+	return LevelError, UnknownLevelError
+}
+
+// Logger is leveled structured logger. Key-value pairs are passed in alternating order
+// after message, e.g. logger.Warn("fail stat", "path", path, "error", err)
 type Logger interface {
-	Infof(format string, a ...interface{})
-	Infoln(a ...interface{})
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text"
+	LogFormatJSON LogFormat = "json"
+)
+
+var UnknownLogFormatError error = errors.New("unknown log format")
+
+// Creates logger which writes entries with provided level or higher in text or json format
+func NewLogger(w io.Writer, format LogFormat, level Level) (Logger, error) {
+	switch format {
+	case LogFormatText, "":
+		return &writerLogger{w: w, level: level, format: formatTextEntry}, nil
+	case LogFormatJSON:
+		return &writerLogger{w: w, level: level, format: formatJSONEntry}, nil
+	}
+	return nil, UnknownLogFormatError
+}
+
+// Returns provided logger or default one, which writes text to stderr.
+// Without verbose output only errors are written
+func loggerOrDefault(logger Logger, verboseOutput bool) Logger {
+	if logger != nil {
+		return logger
+	}
+
+	level := LevelError
+	if verboseOutput {
+		level = LevelDebug
+	}
+	logger, _ = NewLogger(os.Stderr, LogFormatText, level)
+	return logger
+}
+
+type writerLogger struct {
+	mu     sync.Mutex // entries are written from parallel cleaners
+	w      io.Writer
+	level  Level
+	format func(level Level, msg string, keysAndValues []interface{}) []byte
+}
+
+func (l *writerLogger) log(level Level, msg string, keysAndValues []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	entry := l.format(level, msg, keysAndValues)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(entry)
+}
+
+func (l *writerLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(LevelDebug, msg, keysAndValues)
+}
+
+func (l *writerLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(LevelInfo, msg, keysAndValues)
+}
+
+func (l *writerLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(LevelWarn, msg, keysAndValues)
+}
+
+func (l *writerLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log(LevelError, msg, keysAndValues)
+}
+
+// Formats entry like "WARN: fail stat path=/tmp/file error="permission denied"". Info entries have no prefix
+func formatTextEntry(level Level, msg string, keysAndValues []interface{}) []byte {
+	var b strings.Builder
+	if level != LevelInfo {
+		b.WriteString(strings.ToUpper(level.String()))
+		b.WriteString(": ")
+	}
+	b.WriteString(msg)
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, value := keyValue(keysAndValues, i)
+		text := fmt.Sprint(value)
+		if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+			text = fmt.Sprintf("%q", text)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, text)
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// Formats entry as single line JSON object with time, level and msg fields
+func formatJSONEntry(level Level, msg string, keysAndValues []interface{}) []byte {
+	fields := make(map[string]interface{}, len(keysAndValues)/2+3)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, value := keyValue(keysAndValues, i)
+		switch v := value.(type) {
+		case error:
+			value = v.Error()
+		case fmt.Stringer:
+			value = v.String()
+		}
+		fields[key] = value
+	}
+	fields["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	fields["level"] = level.String()
+	fields["msg"] = msg
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"level": level.String(), "msg": msg, "error": err.Error()})
+	}
+	return append(data, '\n')
+}
+
+// Returns key-value pair started from index i. Value of the last key without pair is empty
+func keyValue(keysAndValues []interface{}, i int) (string, interface{}) {
+	key := fmt.Sprint(keysAndValues[i])
+	if i+1 >= len(keysAndValues) {
+		return key, ""
+	}
+	return key, keysAndValues[i+1]
 }
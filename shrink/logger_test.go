@@ -0,0 +1,84 @@
+package shrink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextLoggerFunc(t *testing.T) {
+	testCases := []struct {
+		alias string
+		level Level
+		write func(logger Logger)
+		want  string
+	}{
+		{"Test info without prefix", LevelDebug, func(l Logger) { l.Info("notices written", "packages", 2) }, "notices written packages=2\n"},
+		{"Test quoted values", LevelDebug, func(l Logger) { l.Warn("fail stat", "path", "/a b", "error", errors.New("denied")) }, "WARN: fail stat path=\"/a b\" error=denied\n"},
+		{"Test key without value", LevelDebug, func(l Logger) { l.Debug("removing", "path") }, "DEBUG: removing path=\"\"\n"},
+		{"Test filtered by level", LevelError, func(l Logger) { l.Warn("fail stat") }, ""},
+		{"Test error passes level", LevelError, func(l Logger) { l.Error("fail collect notices") }, "ERROR: fail collect notices\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := NewLogger(&buf, LogFormatText, tc.level)
+			assert.Nil(t, err)
+
+			tc.write(logger)
+			assert.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func TestJSONLoggerFunc(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, LogFormatJSON, LevelInfo)
+	assert.Nil(t, err)
+
+	logger.Debug("removing", "path", "/a")
+	logger.Warn("fail remove", "path", "/a", "error", errors.New("denied"), "match", &Match{Kind: MatchExtension, Rule: &Rule{Kind: RuleExtension, Pattern: ".md", Source: SourceFlag}})
+
+	entry := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "fail remove", entry["msg"])
+	assert.Equal(t, "/a", entry["path"])
+	assert.Equal(t, "denied", entry["error"])
+	assert.Equal(t, `extension ".md" from flag`, entry["match"])
+	assert.NotEmpty(t, entry["time"])
+
+	_, err = NewLogger(&buf, "xml", LevelInfo)
+	assert.Equal(t, UnknownLogFormatError, err)
+}
+
+func TestParseLevelFunc(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	assert.Nil(t, err)
+	assert.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Equal(t, UnknownLevelError, err)
+}
+
+func TestNewShrinkerLogsInvalidRulesFunc(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, LogFormatText, LevelInfo)
+	assert.Nil(t, err)
+
+	memFS := fs.NewMemFS("/project", newMemTree())
+	_, err = NewShrinker(&Config{
+		CheckPath: "/project/node_modules",
+		Rules:     NewRules(RuleInclude, SourceFlag, []string{"*.?!.**"}),
+		Logger:    logger,
+		FS:        memFS,
+		Walker:    memFS,
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "skip invalid rules")
+}
@@ -0,0 +1,257 @@
+package shrink
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+type Decision int
+
+const (
+	DecisionNone   Decision = iota // matcher has no opinion about entry
+	DecisionRemove                 // entry is removed, directories as a whole
+	DecisionKeep                   // entry is kept with all nested entries, wins over any remove decision
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecisionRemove:
+		return "remove"
+	case DecisionKeep:
+		return "keep"
+	}
+	return "none"
+}
+
+// Entry is a file or directory checked by matchers. Size and modification time are read on first use
+type Entry struct {
+	Path    string // full path
+	RelPath string // path relative to checking directory with slash separators
+	Name    string
+	IsDir   bool
+	Package string // name of package which owns entry, empty for files outside of node_modules
+
+	fs      FS
+	info    FileInfoI
+	stat    *FileStat // total stat of directory
+	statErr error
+}
+
+func newEntry(fs FS, checkPath, osPathname string, de FileInfoI) *Entry {
+	relPath, err := filepath.Rel(checkPath, osPathname)
+	if err != nil {
+		relPath = osPathname
+	}
+
+	return &Entry{
+		Path:    osPathname,
+		RelPath: filepath.ToSlash(relPath),
+		Name:    de.Name(),
+		IsDir:   de.IsDir(),
+		Package: packageNameFromPath(osPathname),
+		fs:      fs,
+		info:    de,
+	}
+}
+
+// Returns size of file or total size of directory. Zero if entry can't be read
+func (e *Entry) Size() int64 {
+	if !e.IsDir {
+		return e.info.Size()
+	}
+
+	if e.stat == nil && e.statErr == nil {
+		e.stat, e.statErr = e.fs.Stat(e.Path, true)
+	}
+	if e.statErr != nil {
+		return 0
+	}
+	return e.stat.Size()
+}
+
+// Returns modification time of entry itself. Zero time if entry can't be read
+func (e *Entry) ModTime() time.Time {
+	return e.info.ModTime()
+}
+
+// Matcher decides fate of entry in addition to rules. Building blocks below return
+// DecisionRemove when their condition holds, so they can be combined with And, Or and Not
+// and turned into protection with Keep
+type Matcher interface {
+	Match(ctx context.Context, entry *Entry) Decision
+}
+
+type MatcherFunc func(ctx context.Context, entry *Entry) Decision
+
+func (f MatcherFunc) Match(ctx context.Context, entry *Entry) Decision {
+	return f(ctx, entry)
+}
+
+func decide(condition bool) Decision {
+	if condition {
+		return DecisionRemove
+	}
+	return DecisionNone
+}
+
+// Returns DecisionRemove if all matchers remove entry, DecisionKeep if any of them keeps it
+func And(matchers ...Matcher) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		decision := DecisionNone
+		for _, matcher := range matchers {
+			switch matcher.Match(ctx, entry) {
+			case DecisionKeep:
+				return DecisionKeep
+			case DecisionNone:
+				return DecisionNone
+			}
+			decision = DecisionRemove
+		}
+		return decision
+	})
+}
+
+// Returns DecisionKeep if any matcher keeps entry, otherwise DecisionRemove if any of them removes it
+func Or(matchers ...Matcher) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		decision := DecisionNone
+		for _, matcher := range matchers {
+			switch matcher.Match(ctx, entry) {
+			case DecisionKeep:
+				return DecisionKeep
+			case DecisionRemove:
+				decision = DecisionRemove
+			}
+		}
+		return decision
+	})
+}
+
+// Returns DecisionRemove if matcher has no opinion about entry and DecisionNone otherwise
+func Not(matcher Matcher) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		return decide(matcher.Match(ctx, entry) == DecisionNone)
+	})
+}
+
+// Turns remove decision of matcher into protection of entry
+func Keep(matcher Matcher) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		if matcher.Match(ctx, entry) == DecisionRemove {
+			return DecisionKeep
+		}
+		return DecisionNone
+	})
+}
+
+// Matches files only, useful with size and age matchers as directories are removed as a whole
+func IsFile() Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		return decide(!entry.IsDir)
+	})
+}
+
+// Matches entries with size not less than provided one
+func LargerThan(size int64) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		return decide(entry.Size() >= size)
+	})
+}
+
+// Matches entries with size not greater than provided one
+func SmallerThan(size int64) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		return decide(entry.Size() <= size)
+	})
+}
+
+// Matches entries which weren't modified during provided duration
+func OlderThan(age time.Duration) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		modTime := entry.ModTime()
+		return decide(!modTime.IsZero() && time.Since(modTime) > age)
+	})
+}
+
+// Matches entries modified during provided duration
+func NewerThan(age time.Duration) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		modTime := entry.ModTime()
+		return decide(!modTime.IsZero() && time.Since(modTime) <= age)
+	})
+}
+
+// Matches relative path of entry by shell pattern, e.g. "node_modules/*/docs"
+func PathGlob(pattern string) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		matched, _ := path.Match(pattern, entry.RelPath)
+		return decide(matched)
+	})
+}
+
+// Matches entries of packages with provided names or shell patterns, e.g. "@types/*"
+func InPackage(names ...string) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		if entry.Package == "" {
+			return DecisionNone
+		}
+
+		for _, name := range names {
+			if matched, _ := path.Match(name, entry.Package); matched {
+				return DecisionRemove
+			}
+		}
+		return DecisionNone
+	})
+}
+
+// Decides fate of entry with rules of filter and custom matchers. Matcher protection wins over everything,
+// then exclude and include rules of filter go, custom remove decisions are used for entries not matched by rules.
+// Entries to remove are checked by conditions at the end.
+// Checking directory itself is never removed or excluded, whatever its name is
+func (sh *Shrinker) match(ctx context.Context, osPathname string, de FileInfoI) *Match {
+	if osPathname == sh.checkPath {
+		return noMatch
+	}
+
+	entry := newEntry(sh.fs, sh.checkPath, osPathname, de)
+	match := sh.matchEntry(ctx, entry, de)
+	if !match.Removes() || sh.conditions.isEmpty() {
+		return match
+	}
+
+	if reason := sh.conditions.check(entry, time.Now()); reason != "" {
+		rejected := *match
+		rejected.Rejected = reason
+		return &rejected
+	}
+	return match
+}
+
+func (sh *Shrinker) matchEntry(ctx context.Context, entry *Entry, de FileInfoI) *Match {
+	match := sh.filter.MatchInPackage(entry.Package, de)
+	if len(sh.matchers) == 0 {
+		return match
+	}
+
+	custom := DecisionNone
+	for _, matcher := range sh.matchers {
+		decision := matcher.Match(ctx, entry)
+		if decision == DecisionKeep {
+			return &Match{Kind: MatchCustomKeep}
+		}
+		if decision == DecisionRemove {
+			custom = DecisionRemove
+		}
+	}
+
+	if match.Kind == MatchNone && custom == DecisionRemove {
+		return &Match{Kind: MatchCustomRemove}
+	}
+	return match
+}
@@ -0,0 +1,111 @@
+package shrink
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/walker"
+	"github.com/stretchr/testify/assert"
+)
+
+func decisionMatcher(decision Decision) Matcher {
+	return MatcherFunc(func(ctx context.Context, entry *Entry) Decision {
+		return decision
+	})
+}
+
+func TestMatcherCombinatorsFunc(t *testing.T) {
+	remove, keep, none := decisionMatcher(DecisionRemove), decisionMatcher(DecisionKeep), decisionMatcher(DecisionNone)
+
+	testCases := []struct {
+		alias   string
+		matcher Matcher
+		want    Decision
+	}{
+		{"Test and of removes", And(remove, remove), DecisionRemove},
+		{"Test and with none", And(remove, none), DecisionNone},
+		{"Test and with keep", And(remove, keep), DecisionKeep},
+		{"Test empty and", And(), DecisionNone},
+		{"Test or with remove", Or(none, remove), DecisionRemove},
+		{"Test or with keep", Or(remove, keep), DecisionKeep},
+		{"Test or of nones", Or(none, none), DecisionNone},
+		{"Test not of none", Not(none), DecisionRemove},
+		{"Test not of remove", Not(remove), DecisionNone},
+		{"Test keep of remove", Keep(remove), DecisionKeep},
+		{"Test keep of none", Keep(none), DecisionNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.matcher.Match(context.Background(), &Entry{}))
+		})
+	}
+}
+
+func TestMatcherConditionsFunc(t *testing.T) {
+	entry := &Entry{
+		Path:    "/p/node_modules/@types/node/index.d.ts",
+		RelPath: "node_modules/@types/node/index.d.ts",
+		Name:    "index.d.ts",
+		Package: "@types/node",
+		info:    NewFileInfoWithStat("index.d.ts", 0644, 2048, time.Now()),
+	}
+
+	testCases := []struct {
+		alias   string
+		matcher Matcher
+		want    Decision
+	}{
+		{"Test larger than", LargerThan(1024), DecisionRemove},
+		{"Test not larger than", LargerThan(4096), DecisionNone},
+		{"Test smaller than", SmallerThan(4096), DecisionRemove},
+		{"Test path glob", PathGlob("node_modules/*/*/*.d.ts"), DecisionRemove},
+		{"Test path glob mismatch", PathGlob("node_modules/*.d.ts"), DecisionNone},
+		{"Test package pattern", InPackage("lodash", "@types/*"), DecisionRemove},
+		{"Test other package", InPackage("lodash"), DecisionNone},
+		{"Test is file", IsFile(), DecisionRemove},
+		{"Test composed", And(InPackage("@types/*"), Not(LargerThan(4096))), DecisionRemove},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.matcher.Match(context.Background(), entry))
+		})
+	}
+}
+
+func TestShrinkerMatchFunc(t *testing.T) {
+	sh := &Shrinker{
+		checkPath: "/p",
+		filter: NewRulesFilter([]*Rule{
+			{Kind: RuleInclude, Pattern: "test", Source: SourceFlag},
+			{Kind: RuleExclude, Pattern: "keep", Source: SourceFlag},
+		}),
+		matchers: []Matcher{
+			InPackage("a"),
+			Keep(PathGlob("node_modules/b/*")),
+		},
+	}
+
+	testCases := []struct {
+		alias string
+		path  string
+		isDir bool
+		want  MatchKind
+	}{
+		{"Test rule wins over custom remove", "/p/node_modules/a/test", true, MatchIncludeName},
+		{"Test custom remove", "/p/node_modules/a/index.js", false, MatchCustomRemove},
+		{"Test custom keep wins over rule", "/p/node_modules/b/test", true, MatchCustomKeep},
+		{"Test exclude rule", "/p/node_modules/c/keep", true, MatchExcludeName},
+		{"Test checking path is skipped", "/p", true, MatchNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			match := sh.match(context.Background(), tc.path, NewFileInfo(filepath.Base(tc.path), tc.isDir, !tc.isDir))
+			assert.Equal(t, tc.want, match.Kind)
+		})
+	}
+}
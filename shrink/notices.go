@@ -0,0 +1,264 @@
+package shrink
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+const DefaultNoticesFileName = "THIRD_PARTY_NOTICES"
+
+// base name of license text with optional suffix separated by dot, dash or underscore (LICENSE-MIT, COPYING.LESSER)
+var licenseFileNameRegExp *regexp.Regexp = regexp.MustCompile(`(?i)^(licen[cs]e|copying|notice)([-_.][a-z0-9][a-z0-9.+_-]*)?$`)
+
+// extensions of code and data files, which can have license-like names (license.js, notice-utils.d.ts)
+var nonLicenseFileExt []string = []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx", ".json", ".map", ".coffee", ".node", ".css", ".scss", ".less", ".yml", ".yaml", ".sh", ".py"}
+
+var (
+	noticesHeavySeparator string = strings.Repeat("=", 80)
+	noticesLightSeparator string = strings.Repeat("-", 80)
+)
+
+// Checks is provided file name looks like license text (LICENSE, LICENSE.md, license-mit.txt, COPYING, NOTICE etc.).
+// Code and data files with such names (license.js, licenses.json) aren't license texts
+func isLicenseFileName(name string) bool {
+	if !licenseFileNameRegExp.MatchString(name) {
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, codeExt := range nonLicenseFileExt {
+		if ext == codeExt {
+			return false
+		}
+	}
+	return true
+}
+
+type noticesCollector struct {
+	packages map[string]*packageManifest // package dir -> manifest
+	texts    map[string][]string         // dir of license files -> license texts
+}
+
+func newNoticesCollector() *noticesCollector {
+	return &noticesCollector{
+		packages: make(map[string]*packageManifest),
+		texts:    make(map[string][]string),
+	}
+}
+
+func (nc *noticesCollector) AddManifest(dir string, manifest *packageManifest) {
+	if manifest.Name == "" {
+		return // nested package.json files without name (e.g. {"type": "module"}) are not packages
+	}
+	nc.packages[dir] = manifest
+}
+
+func (nc *noticesCollector) AddLicenseText(dir string, text string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return
+	}
+	nc.texts[dir] = append(nc.texts[dir], text)
+}
+
+// Returns dir of package which owns license texts of provided dir: the dir itself or root of package
+// containing it, e.g. for dist/LICENSE without own package.json
+func (nc *noticesCollector) owner(dir string) (string, bool) {
+	if _, exists := nc.packages[dir]; exists {
+		return dir, true
+	}
+	if root := packageRootFromPath(dir); root != "" {
+		if _, exists := nc.packages[root]; exists {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// Groups license texts by packages. Texts outside of known packages are keyed by package name from path
+// or directory name, so attribution isn't lost even without package.json
+func (nc *noticesCollector) groupTexts() (packageTexts, orphanTexts map[string][]string) {
+	packageTexts = make(map[string][]string)
+	orphanTexts = make(map[string][]string)
+
+	dirs := make([]string, 0, len(nc.texts))
+	for dir := range nc.texts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		if owner, ok := nc.owner(dir); ok {
+			packageTexts[owner] = append(packageTexts[owner], nc.texts[dir]...)
+			continue
+		}
+
+		name := packageNameFromPath(dir)
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		orphanTexts[name] = append(orphanTexts[name], nc.texts[dir]...)
+	}
+	return packageTexts, orphanTexts
+}
+
+// Renders collected notices. Identical license texts are written only once with list of all packages using it
+func (nc *noticesCollector) Render() []byte {
+	textOrder := make([]string, 0)
+	textPackages := make(map[string]map[string]struct{})
+	textLicenses := make(map[string]map[string]struct{})
+	withoutText := make(map[string]map[string]struct{})
+
+	addText := func(text, pkg string) {
+		if _, exists := textPackages[text]; !exists {
+			textOrder = append(textOrder, text)
+		}
+		addToSet(textPackages, text, pkg)
+	}
+
+	packageTexts, orphanTexts := nc.groupTexts()
+	for name, texts := range orphanTexts {
+		for _, text := range texts {
+			addText(text, name)
+		}
+	}
+
+	for dir, manifest := range nc.packages {
+		texts := packageTexts[dir]
+		if len(texts) == 0 {
+			license := manifest.LicenseName()
+			if license == "" {
+				license = "UNKNOWN"
+			}
+			addToSet(withoutText, license, manifest.ID())
+			continue
+		}
+
+		for _, text := range texts {
+			addText(text, manifest.ID())
+			if license := manifest.LicenseName(); license != "" {
+				addToSet(textLicenses, text, license)
+			}
+		}
+	}
+
+	// packages are walked in random order, so sort by packages list for stable output
+	sort.Slice(textOrder, func(i, j int) bool {
+		left := strings.Join(sortedSet(textPackages[textOrder[i]]), ", ")
+		right := strings.Join(sortedSet(textPackages[textOrder[j]]), ", ")
+		if left == right {
+			return textOrder[i] < textOrder[j]
+		}
+		return left < right
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("THIRD-PARTY SOFTWARE NOTICES\n\n")
+	buf.WriteString("This file contains license texts of third-party packages, collected by node_shrinker.\n")
+
+	for _, text := range textOrder {
+		fmt.Fprintf(&buf, "\n%s\n", noticesHeavySeparator)
+		fmt.Fprintf(&buf, "Packages: %s\n", strings.Join(sortedSet(textPackages[text]), ", "))
+		if licenses := sortedSet(textLicenses[text]); len(licenses) != 0 {
+			fmt.Fprintf(&buf, "License: %s\n", strings.Join(licenses, ", "))
+		}
+		fmt.Fprintf(&buf, "%s\n\n%s\n", noticesLightSeparator, text)
+	}
+
+	if len(withoutText) != 0 {
+		fmt.Fprintf(&buf, "\n%s\n", noticesHeavySeparator)
+		buf.WriteString("Packages without license text\n")
+		fmt.Fprintf(&buf, "%s\n\n", noticesLightSeparator)
+		licenses := make([]string, 0, len(withoutText))
+		for license := range withoutText {
+			licenses = append(licenses, license)
+		}
+		sort.Strings(licenses)
+
+		for _, license := range licenses {
+			fmt.Fprintf(&buf, "%s: %s\n", license, strings.Join(sortedSet(withoutText[license]), ", "))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func (nc *noticesCollector) PackagesCount() int {
+	return len(nc.packages)
+}
+
+func addToSet(sets map[string]map[string]struct{}, key, value string) {
+	set, exists := sets[key]
+	if !exists {
+		set = make(map[string]struct{})
+		sets[key] = set
+	}
+	set[value] = struct{}{}
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Walks through checking path and collects license texts and package.json license fields of every package
+func (sh *Shrinker) collectNotices() (*noticesCollector, error) {
+	collector := newNoticesCollector()
+
+	err := sh.walker.Walk(sh.checkPath, func(osPathname string, de FileInfoI) error {
+		if !de.IsRegular() {
+			return nil
+		}
+
+		name := de.Name()
+		if name != PackageManifestName && !isLicenseFileName(name) {
+			return nil
+		}
+
+		data, err := sh.fs.ReadFile(osPathname)
+		if err != nil {
+			sh.logger.Warn("fail read", "path", osPathname, "error", err)
+			return nil
+		}
+
+		dir := filepath.Dir(osPathname)
+		if name == PackageManifestName {
+			manifest, err := parsePackageManifest(data)
+			if err != nil {
+				sh.logger.Warn("fail parse", "path", osPathname, "error", err)
+				return nil
+			}
+			collector.AddManifest(dir, manifest)
+			return nil
+		}
+
+		collector.AddLicenseText(dir, string(data))
+		return nil
+	}, sh.fileFilterErrCallback)
+
+	return collector, err
+}
+
+func (sh *Shrinker) writeNotices() error {
+	collector, err := sh.collectNotices()
+	if err != nil {
+		return err
+	}
+
+	if err = sh.fs.WriteFile(sh.noticesFile, collector.Render()); err != nil {
+		return err
+	}
+
+	sh.logger.Info("notices written", "path", sh.noticesFile, "packages", collector.PackagesCount())
+	return nil
+}
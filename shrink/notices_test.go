@@ -0,0 +1,98 @@
+package shrink
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLicenseFileNameFunc(t *testing.T) {
+	testCases := []struct {
+		alias string
+		name  string
+		want  bool
+	}{
+		{"Test upper case license", "LICENSE", true},
+		{"Test license with extension", "license.md", true},
+		{"Test british spelling", "Licence.txt", true},
+		{"Test license with suffix", "LICENSE-MIT", true},
+		{"Test copying file", "COPYING", true},
+		{"Test notice file", "NOTICE.txt", true},
+		{"Test regular file", "index.js", false},
+		{"Test file with license in the middle", "check-license.js", false},
+		{"Test license with text suffix", "LICENSE.BSD", true},
+		{"Test copying with suffix", "COPYING.LESSER", true},
+		{"Test license code", "license.js", false},
+		{"Test licenses data", "licenses.json", false},
+		{"Test notice code", "notice-utils.js", false},
+		{"Test copying typings", "copying.d.ts", false},
+		{"Test longer word", "Noticeable.md", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, isLicenseFileName(tc.name), fmt.Sprintf("Input: %s", tc.name))
+		})
+	}
+}
+
+func TestManifestLicenseNameFunc(t *testing.T) {
+	testCases := []struct {
+		alias string
+		input string
+		want  string
+	}{
+		{"Test string license", `{"name": "a", "license": "MIT"}`, "MIT"},
+		{"Test object license", `{"name": "a", "license": {"type": "ISC", "url": "http://example.com"}}`, "ISC"},
+		{"Test licenses array", `{"name": "a", "licenses": [{"type": "MIT"}, {"type": "Apache-2.0"}]}`, "MIT OR Apache-2.0"},
+		{"Test without license", `{"name": "a"}`, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			manifest, err := parsePackageManifest([]byte(tc.input))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want, manifest.LicenseName(), fmt.Sprintf("Input: %s", tc.input))
+		})
+	}
+}
+
+func TestNoticesRenderDeduplication(t *testing.T) {
+	collector := newNoticesCollector()
+
+	collector.AddManifest("/nm/a", &packageManifest{Name: "a", Version: "1.0.0", License: []byte(`"MIT"`)})
+	collector.AddManifest("/nm/b", &packageManifest{Name: "b", Version: "2.0.0", License: []byte(`"MIT"`)})
+	collector.AddManifest("/nm/c", &packageManifest{Name: "c", Version: "3.0.0", License: []byte(`"ISC"`)})
+	collector.AddManifest("/nm/c/lib", &packageManifest{})
+
+	collector.AddLicenseText("/nm/a", "MIT License\r\n\r\nCopyright a\n")
+	collector.AddLicenseText("/nm/b", "MIT License\n\nCopyright a")
+	collector.AddLicenseText("/nm/orphan", "Orphan text")
+
+	output := string(collector.Render())
+
+	assert.Equal(t, 1, strings.Count(output, "Copyright a"), "identical texts must be written once")
+	assert.Contains(t, output, "Packages: a@1.0.0, b@2.0.0\nLicense: MIT\n")
+	assert.Contains(t, output, "ISC: c@3.0.0\n")
+	assert.Contains(t, output, "Packages: orphan\n"+noticesLightSeparator+"\n\nOrphan text\n", "text without package is kept")
+	assert.Equal(t, 3, collector.PackagesCount())
+}
+
+func TestNoticesSubdirectoryTextFunc(t *testing.T) {
+	collector := newNoticesCollector()
+
+	collector.AddLicenseText("/p/node_modules/a/dist", "Bundled text")
+	collector.AddLicenseText("/p/node_modules/@s/b/lib/vendor", "Vendor text")
+	collector.AddLicenseText("/p/node_modules/c/lib", "Text of package without manifest")
+	collector.AddManifest("/p/node_modules/a", &packageManifest{Name: "a", Version: "1.0.0", License: []byte(`"MIT"`)})
+	collector.AddManifest("/p/node_modules/@s/b", &packageManifest{Name: "@s/b", Version: "2.0.0"})
+
+	output := string(collector.Render())
+
+	assert.Contains(t, output, "Packages: a@1.0.0\nLicense: MIT\n"+noticesLightSeparator+"\n\nBundled text\n")
+	assert.Contains(t, output, "Packages: @s/b@2.0.0\n"+noticesLightSeparator+"\n\nVendor text\n")
+	assert.Contains(t, output, "Packages: c\n"+noticesLightSeparator+"\n\nText of package without manifest\n")
+	assert.NotContains(t, output, "Packages without license text")
+}
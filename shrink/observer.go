@@ -0,0 +1,70 @@
+package shrink
+
+import (
+	. "github.com/icecream78/node_shrinker/fs"
+)
+
+type SkipReason string
+
+const (
+	SkipExcluded  SkipReason = "excluded by rule"             // entry and all nested entries are kept
+	SkipVetoed    SkipReason = "vetoed by observer"           // OnMatch returned false
+	SkipProtected SkipReason = "protected file"               // notices file written before cleanup or state cache
+	SkipCached    SkipReason = "unchanged since previous run" // package was shrunk with the same rules, nested packages are still checked
+)
+
+// MatchEvent is sent for entry matched by remove rule before it's removed
+type MatchEvent struct {
+	Path  string
+	IsDir bool
+	Match *Match
+}
+
+type RemoveEvent struct {
+	Path       string
+	IsDir      bool
+	Match      *Match // nil for entries removed by Remove
+	Size       int64
+	FilesCount int64
+}
+
+type SkipEvent struct {
+	Path   string
+	IsDir  bool
+	Reason SkipReason
+	Match  *Match // rule which matched entry, nil for protected files and cached packages
+}
+
+type ErrorEvent struct {
+	Path string
+	Err  error
+}
+
+// Observer receives events of DryRun, Clean, Candidates and Remove.
+// OnMatch is called from walking goroutine one by one, OnRemove and OnError
+// can be called concurrently from cleaners. Embed NopObserver to implement only needed methods
+type Observer interface {
+	// Returning false vetoes removing of entry. Entries nested into vetoed directory are checked as usual
+	OnMatch(event *MatchEvent) bool
+	OnRemove(event *RemoveEvent)
+	OnSkip(event *SkipEvent)
+	OnError(event *ErrorEvent)
+	// Called with total stats when run is finished
+	OnDone(stats *FileStat)
+}
+
+// NopObserver allows every removal and ignores all events
+type NopObserver struct{}
+
+func (NopObserver) OnMatch(event *MatchEvent) bool { return true }
+func (NopObserver) OnRemove(event *RemoveEvent)    {}
+func (NopObserver) OnSkip(event *SkipEvent)        {}
+func (NopObserver) OnError(event *ErrorEvent)      {}
+func (NopObserver) OnDone(stats *FileStat)         {}
+
+func observerOrDefault(observer Observer) Observer {
+	if observer != nil {
+		return observer
+	}
+	return NopObserver{}
+}
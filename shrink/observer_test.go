@@ -0,0 +1,83 @@
+package shrink
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	NopObserver
+
+	mu      sync.Mutex
+	veto    string
+	matched []string
+	removed []string
+	skipped map[string]SkipReason
+	done    *FileStat
+}
+
+func (o *recordingObserver) OnMatch(event *MatchEvent) bool {
+	o.matched = append(o.matched, filepath.Base(event.Path))
+	return filepath.Base(event.Path) != o.veto
+}
+
+func (o *recordingObserver) OnRemove(event *RemoveEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.removed = append(o.removed, filepath.Base(event.Path))
+}
+
+func (o *recordingObserver) OnSkip(event *SkipEvent) {
+	o.skipped[filepath.Base(event.Path)] = event.Reason
+}
+
+func (o *recordingObserver) OnDone(stats *FileStat) {
+	o.done = stats
+}
+
+func TestObserverFunc(t *testing.T) {
+	root, err := ioutil.TempDir("", "observer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"a/test", "b/test", "keep/test"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	for _, file := range []string{"a/test/x.js", "b/test/y.js", "keep/test/z.js", "a/README.md", "b/CHANGELOG.md"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(root, file), []byte("data"), 0644))
+	}
+
+	observer := &recordingObserver{veto: "CHANGELOG.md", skipped: make(map[string]SkipReason)}
+	sh, err := NewShrinker(&Config{
+		CheckPath:      root,
+		ConcurentLimit: 2,
+		Observer:       observer,
+		Rules: []*Rule{
+			{Kind: RuleInclude, Pattern: "test", Source: SourceFlag},
+			{Kind: RuleExtension, Pattern: ".md", Source: SourceFlag},
+			{Kind: RuleExclude, Pattern: "keep", Source: SourceFlag},
+		},
+	})
+	assert.Nil(t, err)
+
+	stats := sh.Clean(context.Background())
+
+	sort.Strings(observer.matched)
+	sort.Strings(observer.removed)
+	assert.Equal(t, []string{"CHANGELOG.md", "README.md", "test", "test"}, observer.matched)
+	assert.Equal(t, []string{"README.md", "test", "test"}, observer.removed)
+	assert.Equal(t, map[string]SkipReason{"CHANGELOG.md": SkipVetoed, "keep": SkipExcluded}, observer.skipped)
+	assert.Equal(t, stats, observer.done)
+	assert.Equal(t, int64(3), stats.FilesCount())
+
+	assert.FileExists(t, filepath.Join(root, "b/CHANGELOG.md"))
+	assert.FileExists(t, filepath.Join(root, "keep/test/z.js"))
+}
@@ -0,0 +1,89 @@
+package shrink
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// manifest file of every package and of project itself
+const PackageManifestName = "package.json"
+
+type packageManifest struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version"`
+	License  json.RawMessage   `json:"license"`
+	Licenses []json.RawMessage `json:"licenses"`
+}
+
+func parsePackageManifest(data []byte) (*packageManifest, error) {
+	manifest := packageManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// Returns identifier of package in name@version format
+func (m *packageManifest) ID() string {
+	if m.Version == "" {
+		return m.Name
+	}
+	return m.Name + "@" + m.Version
+}
+
+// Returns license declared in package.json. Handles both modern string form
+// and deprecated object forms ({"type": "MIT"} and "licenses" array)
+func (m *packageManifest) LicenseName() string {
+	if name := parseLicenseValue(m.License); name != "" {
+		return name
+	}
+
+	names := make([]string, 0, len(m.Licenses))
+	for _, raw := range m.Licenses {
+		if name := parseLicenseValue(raw); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " OR ")
+}
+
+func parseLicenseValue(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		return obj.Type
+	}
+	return ""
+}
+
+const nodeModulesDirName = "node_modules"
+
+// Returns name of package which owns provided path. Name is taken from the
+// closest node_modules directory in the path, so nested and scoped packages are handled too.
+// Returns empty string for paths outside of node_modules
+func packageNameFromPath(osPathname string) string {
+	parts := strings.Split(filepath.ToSlash(osPathname), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] != nodeModulesDirName {
+			continue
+		}
+
+		name := parts[i+1]
+		if strings.HasPrefix(name, "@") && i+2 < len(parts) {
+			name = name + "/" + parts[i+2]
+		}
+		return name
+	}
+	return ""
+}
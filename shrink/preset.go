@@ -0,0 +1,230 @@
+package shrink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var (
+	UnknownPresetError   error = errors.New("unknown preset")
+	UnknownPlatformError error = errors.New("unknown platform")
+)
+
+// Preset is a named bundle of rules for common deployment target. Version is increased on every change
+// of rules, it's shown as source of rules. Rules and matchers of extended preset are included
+type Preset struct {
+	Name        string
+	Version     int
+	Description string
+	Extends     string
+
+	RemoveNames   []string // file and directory names or regular expressions
+	RemoveFileExt []string
+	ExcludeNames  []string
+	Matchers      []Matcher // checks which can't be written as name rules
+}
+
+func (p *Preset) Source() RuleSource {
+	return RuleSource(fmt.Sprintf("preset %s v%d", p.Name, p.Version))
+}
+
+// Returns rules of preset and extended presets, rules of base presets go first
+func (p *Preset) Rules() []*Rule {
+	rules := make([]*Rule, 0)
+	if base, err := PresetByName(p.Extends); err == nil {
+		rules = append(rules, base.Rules()...)
+	}
+
+	rules = append(rules, NewRules(RuleExclude, p.Source(), p.ExcludeNames)...)
+	rules = append(rules, NewRules(RuleInclude, p.Source(), p.RemoveNames)...)
+	return append(rules, NewRules(RuleExtension, p.Source(), p.RemoveFileExt)...)
+}
+
+// Returns matchers of preset and extended presets. Matchers without fixed platform keep binaries
+// of provided target platform (node.js name, see ParsePlatform), platform of current OS is used if it's empty
+func (p *Preset) AllMatchers(platform string) []Matcher {
+	if platform == "" {
+		platform = HostPlatform()
+	}
+
+	matchers := make([]Matcher, 0)
+	if base, err := PresetByName(p.Extends); err == nil {
+		matchers = append(matchers, base.AllMatchers(platform)...)
+	}
+	for _, matcher := range p.Matchers {
+		if binaries, ok := matcher.(foreignBinaries); ok && binaries.targetOS == "" {
+			matcher = foreignBinaries{targetOS: platform}
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers
+}
+
+// foreignBinaries removes prebuilt native binaries of other operating systems. Prebuilds are placed
+// in directories named by platform (prebuilds/darwin-x64, lib/binding/node-v93-win32-x64).
+// Empty targetOS is replaced by target platform in Preset.AllMatchers
+type foreignBinaries struct {
+	targetOS string
+}
+
+var knownPlatforms []string = []string{"darwin", "win32", "linux", "freebsd", "openbsd", "sunos", "aix", "android"}
+
+// Returns platform name used by node.js for GOOS
+func nodePlatform(goos string) string {
+	if goos == "windows" {
+		return "win32"
+	}
+	return goos
+}
+
+// Returns node.js name of platform where node_shrinker is run
+func HostPlatform() string {
+	return nodePlatform(runtime.GOOS)
+}
+
+// Returns node.js name of platform. Both node.js names (win32) and Go ones (windows) are accepted
+func ParsePlatform(name string) (string, error) {
+	platform := nodePlatform(name)
+	for _, known := range knownPlatforms {
+		if known == platform {
+			return platform, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", UnknownPlatformError, name)
+}
+
+func (m foreignBinaries) Match(ctx context.Context, entry *Entry) Decision {
+	if !entry.IsDir {
+		return DecisionNone
+	}
+
+	parent := filepath.Base(filepath.Dir(entry.Path))
+	if parent != "prebuilds" && parent != "binding" {
+		return DecisionNone
+	}
+
+	for _, platform := range knownPlatforms {
+		if platform == m.targetOS {
+			continue
+		}
+		if strings.HasPrefix(entry.Name, platform+"-") || strings.Contains(entry.Name, "-"+platform+"-") {
+			return DecisionRemove
+		}
+	}
+	return DecisionNone
+}
+
+// Used in hash of state cache, so preset matchers are told apart
+func (m foreignBinaries) String() string {
+	return "foreign binaries for " + m.targetOS
+}
+
+// bundledPackages removes top level packages which are provided by runtime. Nested copies are kept,
+// they are installed because other version than top level one is required
+type bundledPackages struct {
+	names []string
+}
+
+func (m bundledPackages) Match(ctx context.Context, entry *Entry) Decision {
+	if !entry.IsDir || filepath.Base(filepath.Dir(entry.Path)) != nodeModulesDirName {
+		return DecisionNone
+	}
+
+	for _, name := range m.names {
+		if entry.RelPath == name || entry.RelPath == nodeModulesDirName+"/"+name {
+			return DecisionRemove
+		}
+	}
+	return DecisionNone
+}
+
+func (m bundledPackages) String() string {
+	return "bundled packages " + strings.Join(m.names, ",")
+}
+
+// files of development tools, which aren't used in runtime
+var devToolFileNames []string = []string{
+	".github",
+	".vscode",
+	".idea",
+	".circleci",
+	".travis.yml",
+	".editorconfig",
+	".eslintrc",
+	".eslintrc.js",
+	".eslintrc.json",
+	".eslintignore",
+	".prettierrc",
+	".npmignore",
+	".nycrc",
+	".babelrc",
+}
+
+// DefaultRemoveFileNames isn't used by any preset: package.json is read by module resolution
+var presets []*Preset = []*Preset{
+	{
+		Name:        "safe",
+		Version:     1,
+		Description: "documentation and tests only",
+		RemoveNames: append(append([]string{}, DefaultRemoveDirNames...), "__tests__", "__mocks__", "doc", "docs", "coverage", "README.md", "CHANGELOG.md", "HISTORY.md", "CONTRIBUTING.md"),
+		// license texts must be kept with packages
+		ExcludeNames: []string{"(?i)^licen[cs]e"},
+	},
+	{
+		Name:          "aggressive",
+		Version:       1,
+		Description:   "safe preset plus type definitions, source maps and sources of compiled packages",
+		Extends:       "safe",
+		RemoveNames:   append([]string{"@types"}, devToolFileNames...),
+		RemoveFileExt: append(append([]string{}, DefaultRemoveFileExt...), ".map", ".flow", ".tsx", ".mts", ".cts", ".md", ".markdown", ".tsbuildinfo"),
+	},
+	{
+		Name:        "lambda",
+		Version:     1,
+		Description: "aggressive preset plus native binaries for other platforms than linux and aws-sdk v2 bundled with AWS Lambda runtimes up to nodejs16.x",
+		Extends:     "aggressive",
+		Matchers:    []Matcher{foreignBinaries{targetOS: "linux"}, bundledPackages{names: []string{"aws-sdk"}}},
+	},
+	{
+		Name:        "docker",
+		Version:     1,
+		Description: "aggressive preset plus native binaries for other platforms than linux",
+		Extends:     "aggressive",
+		RemoveNames: []string{"Dockerfile", ".dockerignore", ".gitattributes"},
+		Matchers:    []Matcher{foreignBinaries{targetOS: "linux"}},
+	},
+	{
+		Name:        "electron",
+		Version:     1,
+		Description: "aggressive preset plus native binaries for other platforms than target one (current by default)",
+		Extends:     "aggressive",
+		Matchers:    []Matcher{foreignBinaries{}},
+	},
+}
+
+// Returns all built-in presets
+func Presets() []*Preset {
+	return presets
+}
+
+func PresetByName(name string) (*Preset, error) {
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", UnknownPresetError, name)
+}
+
+// Returns names of all presets, e.g. for help of flags
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+	}
+	return names
+}
@@ -0,0 +1,119 @@
+package shrink
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresetByNameFunc(t *testing.T) {
+	for _, name := range PresetNames() {
+		preset, err := PresetByName(name)
+		if !assert.Nil(t, err, name) {
+			continue
+		}
+		assert.NotEmpty(t, preset.Rules(), name)
+		if preset.Extends != "" {
+			_, err := PresetByName(preset.Extends)
+			assert.Nil(t, err, "%s extends unknown preset", name)
+		}
+	}
+
+	_, err := PresetByName("tiny")
+	assert.True(t, errors.Is(err, UnknownPresetError))
+}
+
+func TestPresetRulesFunc(t *testing.T) {
+	lambda, _ := PresetByName("lambda")
+	safe, _ := PresetByName("safe")
+
+	sources := make(map[RuleSource]int)
+	for _, rule := range lambda.Rules() {
+		sources[rule.Source]++
+	}
+	assert.Equal(t, len(safe.Rules()), sources["preset safe v1"])
+	assert.NotZero(t, sources["preset aggressive v1"])
+	assert.Equal(t, 2, len(lambda.AllMatchers("")))
+}
+
+func TestForeignBinariesFunc(t *testing.T) {
+	testCases := []struct {
+		path string
+		want Decision
+	}{
+		{"/p/node_modules/a/prebuilds/darwin-x64", DecisionRemove},
+		{"/p/node_modules/a/prebuilds/win32-ia32", DecisionRemove},
+		{"/p/node_modules/a/prebuilds/linux-x64", DecisionNone},
+		{"/p/node_modules/a/lib/binding/node-v93-darwin-arm64", DecisionRemove},
+		{"/p/node_modules/a/lib/binding/node-v93-linux-x64", DecisionNone},
+		{"/p/node_modules/a/lib/darwin-x64", DecisionNone},
+	}
+
+	matcher := foreignBinaries{targetOS: "linux"}
+	for _, tc := range testCases {
+		entry := &Entry{Path: tc.path, Name: filepath.Base(tc.path), IsDir: true}
+		assert.Equal(t, tc.want, matcher.Match(context.TODO(), entry), tc.path)
+	}
+}
+
+func TestPresetPlatformFunc(t *testing.T) {
+	electron, _ := PresetByName("electron")
+	docker, _ := PresetByName("docker")
+
+	assert.Contains(t, electron.AllMatchers(""), foreignBinaries{targetOS: HostPlatform()})
+	assert.Contains(t, electron.AllMatchers("win32"), foreignBinaries{targetOS: "win32"})
+	assert.NotContains(t, electron.AllMatchers("win32"), foreignBinaries{targetOS: HostPlatform()})
+	// platform of docker preset is fixed
+	assert.Contains(t, docker.AllMatchers("win32"), foreignBinaries{targetOS: "linux"})
+
+	platform, err := ParsePlatform("windows")
+	assert.Nil(t, err)
+	assert.Equal(t, "win32", platform)
+	_, err = ParsePlatform("plan9")
+	assert.True(t, errors.Is(err, UnknownPlatformError), err)
+}
+
+func TestCleanWithPresetFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", fs.MemTree{
+		"node_modules/a/index.js":                    fs.MemFile("a"),
+		"node_modules/a/index.d.ts":                  fs.MemFile("a"),
+		"node_modules/a/README.md":                   fs.MemFile("a"),
+		"node_modules/a/LICENSE.md":                  fs.MemFile("a"),
+		"node_modules/a/index.js.map":                fs.MemFile("a"),
+		"node_modules/a/prebuilds/darwin-x64/a.node": fs.MemFile("a"),
+		"node_modules/a/prebuilds/linux-x64/a.node":  fs.MemFile("a"),
+		"node_modules/aws-sdk/index.js":              fs.MemFile("a"),
+		"node_modules/a/node_modules/aws-sdk/x.js":   fs.MemFile("a"),
+	})
+
+	lambda, _ := PresetByName("lambda")
+	sh, err := NewShrinker(&Config{
+		CheckPath:      "/project/node_modules",
+		ConcurentLimit: 2,
+		Rules:          append(lambda.Rules(), NewRules(RuleExclude, SourceFlag, []string{"index.d.ts"})...),
+		Matchers:       lambda.AllMatchers(""),
+		FS:             memFS,
+		Walker:         memFS,
+	})
+	assert.Nil(t, err)
+	sh.Clean(context.TODO())
+
+	// user exclude wins over preset, nested aws-sdk isn't provided by runtime
+	assert.Equal(t, []string{
+		"node_modules",
+		"node_modules/a",
+		"node_modules/a/LICENSE.md",
+		"node_modules/a/index.d.ts",
+		"node_modules/a/index.js",
+		"node_modules/a/node_modules",
+		"node_modules/a/node_modules/aws-sdk",
+		"node_modules/a/node_modules/aws-sdk/x.js",
+		"node_modules/a/prebuilds",
+		"node_modules/a/prebuilds/linux-x64",
+		"node_modules/a/prebuilds/linux-x64/a.node",
+	}, memFS.Paths())
+}
@@ -0,0 +1,120 @@
+package shrink
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultProgressInterval time.Duration = 500 * time.Millisecond
+
+// Progress is a snapshot of counters of running Clean or Remove
+type Progress struct {
+	Scanned    int64 // count of checked entries
+	Matched    int64 // count of entries matched by remove rules
+	Removed    int64 // count of removed entries, directory counts as one entry
+	Size       int64 // released space
+	FilesCount int64 // count of removed files, including files of removed directories
+	Elapsed    time.Duration
+	Done       bool // true for the last report
+}
+
+// Returns count of checked entries per second
+func (p Progress) ScanRate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Scanned) / p.Elapsed.Seconds()
+}
+
+// Returns released bytes per second
+func (p Progress) SizeRate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Size) / p.Elapsed.Seconds()
+}
+
+// ProgressFunc receives progress periodically during run and once after run is finished.
+// It's called from single goroutine, so calls never overlap
+type ProgressFunc func(progress Progress)
+
+// counters are updated from walker and cleaners concurrently
+type progressTracker struct {
+	scanned    int64
+	matched    int64
+	removed    int64
+	size       int64
+	filesCount int64
+	startedAt  time.Time
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{startedAt: time.Now()}
+}
+
+func (t *progressTracker) AddScanned() {
+	atomic.AddInt64(&t.scanned, 1)
+}
+
+func (t *progressTracker) AddMatched() {
+	t.AddMatchedCount(1)
+}
+
+// Adds entries which are matched before run, e.g. candidates chosen for Remove
+func (t *progressTracker) AddMatchedCount(count int64) {
+	atomic.AddInt64(&t.matched, count)
+}
+
+func (t *progressTracker) AddRemoved(size, filesCount int64) {
+	atomic.AddInt64(&t.removed, 1)
+	atomic.AddInt64(&t.size, size)
+	atomic.AddInt64(&t.filesCount, filesCount)
+}
+
+func (t *progressTracker) Progress() Progress {
+	return Progress{
+		Scanned:    atomic.LoadInt64(&t.scanned),
+		Matched:    atomic.LoadInt64(&t.matched),
+		Removed:    atomic.LoadInt64(&t.removed),
+		Size:       atomic.LoadInt64(&t.size),
+		FilesCount: atomic.LoadInt64(&t.filesCount),
+		Elapsed:    time.Since(t.startedAt),
+	}
+}
+
+// Calls fn with provided interval until returned function is called. The last report is sent
+// by returned function itself, so progress is complete when it returns
+func (t *progressTracker) Report(fn ProgressFunc, interval time.Duration) (stop func()) {
+	if fn == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fn(t.Progress())
+			case <-done:
+				progress := t.Progress()
+				progress.Done = true
+				fn(progress)
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
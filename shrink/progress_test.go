@@ -0,0 +1,67 @@
+package shrink
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressTrackerFunc(t *testing.T) {
+	tracker := newProgressTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.AddScanned()
+			tracker.AddMatched()
+			tracker.AddRemoved(10, 2)
+		}()
+	}
+	wg.Wait()
+	tracker.AddScanned()
+	tracker.AddMatchedCount(3)
+
+	progress := tracker.Progress()
+	assert.Equal(t, int64(5), progress.Scanned)
+	assert.Equal(t, int64(7), progress.Matched)
+	assert.Equal(t, int64(4), progress.Removed)
+	assert.Equal(t, int64(40), progress.Size)
+	assert.Equal(t, int64(8), progress.FilesCount)
+	assert.False(t, progress.Done)
+}
+
+func TestProgressReportFunc(t *testing.T) {
+	tracker := newProgressTracker()
+
+	reports := make([]Progress, 0)
+	stop := tracker.Report(func(progress Progress) {
+		reports = append(reports, progress)
+	}, time.Millisecond)
+
+	tracker.AddScanned()
+	time.Sleep(10 * time.Millisecond)
+	tracker.AddScanned()
+	stop()
+
+	assert.True(t, len(reports) > 1)
+	last := reports[len(reports)-1]
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(2), last.Scanned)
+	for _, progress := range reports[:len(reports)-1] {
+		assert.False(t, progress.Done)
+	}
+
+	// reporting is disabled without callback
+	tracker.Report(nil, time.Millisecond)()
+}
+
+func TestProgressRateFunc(t *testing.T) {
+	progress := Progress{Scanned: 100, Size: 1000, Elapsed: 2 * time.Second}
+	assert.Equal(t, float64(50), progress.ScanRate())
+	assert.Equal(t, float64(500), progress.SizeRate())
+	assert.Equal(t, float64(0), Progress{Scanned: 100}.ScanRate())
+}
@@ -0,0 +1,134 @@
+package shrink
+
+import "fmt"
+
+type RuleKind int
+
+const (
+	RuleInclude   RuleKind = iota // file/directory name or regular expression
+	RuleExclude                   // file/directory name or regular expression
+	RuleExtension                 // file extension
+)
+
+func (k RuleKind) String() string {
+	switch k {
+	case RuleInclude:
+		return "include"
+	case RuleExclude:
+		return "exclude"
+	case RuleExtension:
+		return "extension"
+	}
+	return "unknown"
+}
+
+// RuleSource shows where rule came from
+type RuleSource string
+
+const (
+	SourceConfig     RuleSource = "config" // name lists of shrink.Config
+	SourceFlag       RuleSource = "flag"
+	SourceConfigFile RuleSource = "config file"
+	SourceDefault    RuleSource = "default set"
+)
+
+type Rule struct {
+	Kind    RuleKind
+	Pattern string
+	Source  RuleSource
+	Package string // name or glob (@scope/*) of packages, which subtrees rule is applied in. Empty for global rules
+}
+
+func (r *Rule) String() string {
+	if r.Package != "" {
+		return fmt.Sprintf("%s %q in package %q from %s", r.Kind, r.Pattern, r.Package, r.Source)
+	}
+	return fmt.Sprintf("%s %q from %s", r.Kind, r.Pattern, r.Source)
+}
+
+// Builds rules with the same kind and source from list of patterns
+func NewRules(kind RuleKind, source RuleSource, patterns []string) []*Rule {
+	rules := make([]*Rule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, &Rule{Kind: kind, Pattern: pattern, Source: source})
+	}
+	return rules
+}
+
+// Builds rules applied only inside packages matching provided name or glob
+func NewPackageRules(pkg string, kind RuleKind, source RuleSource, patterns []string) []*Rule {
+	rules := NewRules(kind, source, patterns)
+	for _, rule := range rules {
+		rule.Package = pkg
+	}
+	return rules
+}
+
+type MatchKind int
+
+const (
+	MatchNone MatchKind = iota
+	MatchIncludeName
+	MatchIncludeRegExp
+	MatchExtension
+	MatchExcludeName
+	MatchExcludeRegExp
+	MatchCustomRemove // removed by custom matcher from Config.Matchers
+	MatchCustomKeep   // protected by custom matcher from Config.Matchers
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case MatchIncludeName:
+		return "include name"
+	case MatchIncludeRegExp:
+		return "include regexp"
+	case MatchExtension:
+		return "extension"
+	case MatchExcludeName:
+		return "exclude name"
+	case MatchExcludeRegExp:
+		return "exclude regexp"
+	case MatchCustomRemove:
+		return "custom matcher"
+	case MatchCustomKeep:
+		return "custom matcher protection"
+	}
+	return "no match"
+}
+
+// Match is a result of checking entry by filter
+type Match struct {
+	Kind     MatchKind
+	Rule     *Rule  // nil if nothing matched
+	Rejected string // reason why entry matched by remove rule is kept by size/age conditions
+}
+
+var noMatch *Match = &Match{Kind: MatchNone}
+
+// Checks is entry should be removed
+func (m *Match) Removes() bool {
+	if m.Rejected != "" {
+		return false
+	}
+	return m.Kind == MatchIncludeName || m.Kind == MatchIncludeRegExp || m.Kind == MatchExtension || m.Kind == MatchCustomRemove
+}
+
+// Checks is entry protected from removing with all nested entries
+func (m *Match) Excludes() bool {
+	return m.Kind == MatchExcludeName || m.Kind == MatchExcludeRegExp || m.Kind == MatchCustomKeep
+}
+
+func (m *Match) String() string {
+	description := m.Kind.String()
+	if m.Rule != nil && m.Rule.Package != "" {
+		description = fmt.Sprintf("%s %q in package %q from %s", m.Kind, m.Rule.Pattern, m.Rule.Package, m.Rule.Source)
+	} else if m.Rule != nil {
+		description = fmt.Sprintf("%s %q from %s", m.Kind, m.Rule.Pattern, m.Rule.Source)
+	}
+
+	if m.Rejected != "" {
+		return fmt.Sprintf("%s, but kept because %s", description, m.Rejected)
+	}
+	return description
+}
@@ -0,0 +1,86 @@
+package shrink
+
+import (
+	"sync"
+
+	. "github.com/icecream78/node_shrinker/fs"
+)
+
+// RuleStat shows how effective rule was during the last run
+type RuleStat struct {
+	Rule       *Rule
+	Matched    int64 // count of matched entries, including ones which failed to be removed
+	Removed    int64 // count of removed entries, in dry run ones which would be removed
+	Size       int64 // released space of removed entries, always zero for exclude rules
+	FilesCount int64 // count of removed files, including files of removed directories
+}
+
+// collects statistics of rules during run. Cleaners work in parallel, so it's guarded by mutex
+type ruleStatsCollector struct {
+	mu    sync.Mutex
+	stats map[*Rule]*RuleStat
+	order []*RuleStat
+}
+
+func newRuleStatsCollector(rules []*Rule) *ruleStatsCollector {
+	collector := &ruleStatsCollector{
+		stats: make(map[*Rule]*RuleStat, len(rules)),
+		order: make([]*RuleStat, 0, len(rules)),
+	}
+
+	for _, rule := range rules {
+		stat := &RuleStat{Rule: rule}
+		collector.stats[rule] = stat
+		collector.order = append(collector.order, stat)
+	}
+	return collector
+}
+
+// Counts entry as soon as it's matched, whatever happens with it later
+func (c *ruleStatsCollector) AddMatch(match *Match) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ruleStat := c.stat(match); ruleStat != nil {
+		ruleStat.Matched++
+	}
+}
+
+// Adds released space of removed entry
+func (c *ruleStatsCollector) AddRemoved(match *Match, stat *FileStat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ruleStat := c.stat(match); ruleStat != nil {
+		ruleStat.Removed++
+		ruleStat.Size += stat.Size()
+		ruleStat.FilesCount += stat.FilesCount()
+	}
+}
+
+// Returns statistics of rule which decided match, nil for unknown rules and matches without rule
+func (c *ruleStatsCollector) stat(match *Match) *RuleStat {
+	if match == nil || match.Rule == nil {
+		return nil
+	}
+	return c.stats[match.Rule]
+}
+
+// Returns copy of collected statistics in order of rules
+func (c *ruleStatsCollector) Stats() []*RuleStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]*RuleStat, 0, len(c.order))
+	for _, stat := range c.order {
+		statCopy := *stat
+		stats = append(stats, &statCopy)
+	}
+	return stats
+}
+
+// Returns statistics of every rule collected during the last DryRun or Clean call.
+// Rules with zero matches likely contain typos
+func (sh *Shrinker) RuleStats() []*RuleStat {
+	return sh.ruleStats.Stats()
+}
@@ -0,0 +1,63 @@
+package shrink
+
+import (
+	"testing"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleStatsCollectorFunc(t *testing.T) {
+	filter := NewRulesFilter([]*Rule{
+		{Kind: RuleInclude, Pattern: "test", Source: SourceFlag},
+		{Kind: RuleInclude, Pattern: "test", Source: SourceConfigFile},
+		{Kind: RuleInclude, Pattern: "tets", Source: SourceFlag},
+		{Kind: RuleExtension, Pattern: ".md", Source: SourceDefault},
+		{Kind: RuleExclude, Pattern: "docs", Source: SourceFlag},
+	})
+	rules := filter.Rules()
+	assert.Len(t, rules, 4)
+	assert.Equal(t, SourceFlag, rules[0].Source)
+
+	collector := newRuleStatsCollector(rules)
+	testMatch := &Match{Kind: MatchIncludeName, Rule: rules[0]}
+	collector.AddMatch(testMatch)
+	collector.AddRemoved(testMatch, NewFileStat("test", "/a/test", 100, 3))
+	collector.AddMatch(testMatch) // failed to be removed
+	mdMatch := &Match{Kind: MatchExtension, Rule: rules[2]}
+	collector.AddMatch(mdMatch)
+	collector.AddRemoved(mdMatch, NewFileStat("a.md", "/a/a.md", 10, 1))
+	collector.AddMatch(mdMatch)
+	collector.AddRemoved(mdMatch, NewFileStat("b.md", "/b/b.md", 20, 1))
+	collector.AddMatch(&Match{Kind: MatchExcludeName, Rule: rules[3]})
+	collector.AddMatch(noMatch)
+	collector.AddMatch(&Match{Kind: MatchIncludeName, Rule: &Rule{Kind: RuleInclude, Pattern: "unknown"}})
+
+	stats := collector.Stats()
+	assert.Len(t, stats, 4)
+
+	testCases := []struct {
+		alias          string
+		stat           *RuleStat
+		wantPattern    string
+		wantMatched    int64
+		wantRemoved    int64
+		wantSize       int64
+		wantFilesCount int64
+	}{
+		{"Test include rule", stats[0], "test", 2, 1, 100, 3},
+		{"Test never matched rule", stats[1], "tets", 0, 0, 0, 0},
+		{"Test extension rule", stats[2], ".md", 2, 2, 30, 2},
+		{"Test exclude rule", stats[3], "docs", 1, 0, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.wantPattern, tc.stat.Rule.Pattern)
+			assert.Equal(t, tc.wantMatched, tc.stat.Matched)
+			assert.Equal(t, tc.wantRemoved, tc.stat.Removed)
+			assert.Equal(t, tc.wantSize, tc.stat.Size)
+			assert.Equal(t, tc.wantFilesCount, tc.stat.FilesCount)
+		})
+	}
+}
@@ -3,10 +3,12 @@ package shrink
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
+	"os"
 	"path"
+	"path/filepath"
 	"sync"
+	"time"
 
 	. "github.com/icecream78/node_shrinker/fs"
 	. "github.com/icecream78/node_shrinker/walker"
@@ -15,65 +17,156 @@ import (
 	color "github.com/logrusorgru/aurora"
 )
 
-var fsManager FS = NewFS() // for test purposes
-var walker Walker
-
 type removeObjInfo struct {
 	isDir    bool
 	filename string
 	fullpath string
+	match    *Match
 }
 
 type Shrinker struct {
-	verboseOutput  bool
+	fs             FS
+	walker         Walker
+	logger         Logger
+	observer       Observer
+	output         io.Writer
 	concurentLimit int
 	checkPath      string
+	noticesFile    string
+	cacheFile      string
+	rulesHash      string
+	linkMode       LinkMode
 	filter         *Filter
+	matchers       []Matcher
+	conditions     Conditions
+	ruleStats      *ruleStatsCollector
+	cache          *cacheRun // state of current Clean, nil if caching is disabled
+
+	progress         *progressTracker
+	onProgress       ProgressFunc
+	progressInterval time.Duration
 }
 
 func NewShrinker(cfg *Config) (*Shrinker, error) {
-	if !pathExists(cfg.CheckPath) {
+	fs := fsOrDefault(cfg.FS)
+	if !pathExists(fs, cfg.CheckPath) {
 		return nil, NotExistError
 	}
 
 	concurentLimit := cfg.ConcurentLimit
-	if concurentLimit == 0 {
+	if concurentLimit < 1 {
 		concurentLimit = 1
 	}
 
-	walker = NewDirWalker(cfg.DryRun)
+	logger := loggerOrDefault(cfg.Logger, cfg.VerboseOutput)
+	filter := NewRulesFilter(cfg.rules())
+	filter.OverrideInPackages(cfg.OverridePackages)
+	if err := filter.Err(); err != nil {
+		logger.Warn("skip invalid rules", "error", err)
+	}
+
+	progressInterval := cfg.ProgressInterval
+	if progressInterval <= 0 {
+		progressInterval = DefaultProgressInterval
+	}
 
 	return &Shrinker{
-		verboseOutput:  cfg.VerboseOutput,
+		fs:             fs,
+		walker:         walkerOrDefault(cfg.Walker, cfg.DryRun),
+		logger:         logger,
+		observer:       observerOrDefault(cfg.Observer),
+		output:         outputOrDefault(cfg.Output),
 		checkPath:      cfg.CheckPath,
-		filter:         NewFilter(cfg.IncludeNames, cfg.ExcludeNames, cfg.RemoveFileExt),
+		noticesFile:    cfg.NoticesFile,
+		cacheFile:      cfg.CacheFile,
+		rulesHash:      rulesHash(filter, cfg.Matchers, cfg.Conditions),
+		linkMode:       cfg.LinkMode,
+		filter:         filter,
+		matchers:       cfg.Matchers,
+		conditions:     cfg.Conditions,
+		ruleStats:      newRuleStatsCollector(filter.Rules()),
 		concurentLimit: concurentLimit,
+
+		progress:         newProgressTracker(),
+		onProgress:       cfg.OnProgress,
+		progressInterval: progressInterval,
 	}, nil
 }
 
 func (sh *Shrinker) DryRun(ctx context.Context) (stats *FileStat) {
-	filesCh := sh.layoutPrinterWrapper(sh.checkPath)
+	sh.ruleStats = newRuleStatsCollector(sh.filter.Rules())
+
+	filesCh := sh.layoutPrinterWrapper(ctx, sh.checkPath)
 	statsCh := sh.runStatGrabber(ctx, filesCh)
 	stats = <-statsCh
 
+	sh.observer.OnDone(stats)
 	return stats
 }
 
 func (sh *Shrinker) Clean(ctx context.Context) (stats *FileStat) {
-	filesCh := sh.inspectPath(sh.checkPath)
+	return sh.clean(ctx, sh.checkPath)
+}
+
+// Removes matched entries of provided directories, which are walked one by one
+func (sh *Shrinker) clean(ctx context.Context, pathes ...string) (stats *FileStat) {
+	if sh.noticesFile != "" {
+		// license texts must be saved before originals are removed, otherwise nothing is deleted
+		if err := sh.writeNotices(); err != nil {
+			sh.logger.Error("fail collect notices", "path", sh.noticesFile, "error", err)
+			sh.observer.OnError(&ErrorEvent{Path: sh.noticesFile, Err: err})
+
+			stats = NewFileStat("result", "result", 0, 0)
+			sh.observer.OnDone(stats)
+			return stats
+		}
+	}
+
+	sh.ruleStats = newRuleStatsCollector(sh.filter.Rules())
+	sh.progress = newProgressTracker()
+	sh.cache = sh.newCacheRun()
+	stopProgress := sh.progress.Report(sh.onProgress, sh.progressInterval)
+
+	filesCh := sh.inspectPath(ctx, pathes...)
 	removeCh := sh.runCleaners(ctx, filesCh)
 	statsCh := sh.runStatGrabber(ctx, removeCh)
 
 	stats = <-statsCh
+	stopProgress()
+
+	// interrupted run doesn't know results of all walked packages
+	if sh.cache != nil && ctx.Err() == nil {
+		if err := sh.writeCache(sh.cache.result(pathes)); err != nil {
+			sh.logger.Error("fail write cache", "path", sh.cacheFile, "error", err)
+			sh.observer.OnError(&ErrorEvent{Path: sh.cacheFile, Err: err})
+		}
+	}
+	sh.cache = nil
+
+	sh.observer.OnDone(stats)
 	return stats
 }
 
-func (sh *Shrinker) inspectPath(path string) chan *removeObjInfo {
+func (sh *Shrinker) inspectPath(ctx context.Context, pathes ...string) chan *removeObjInfo {
 	inspectCh := make(chan *removeObjInfo)
 	go func(ch chan *removeObjInfo) {
 		defer close(ch)
 
-		_ = walker.Walk(sh.checkPath, sh.fileFilterCallback(inspectCh), sh.fileFilterErrCallback)
+		queue := append([]string(nil), pathes...)
+		for len(queue) != 0 {
+			if ctx.Err() != nil {
+				return
+			}
+
+			path := queue[0]
+			queue = queue[1:]
+			_ = sh.walker.Walk(path, sh.fileFilterCallback(ctx, inspectCh), sh.fileFilterErrCallback)
+
+			// nested packages of skipped cached ones could be changed separately
+			if sh.cache != nil {
+				queue = append(queue, sh.takeNested(sh.cache)...)
+			}
+		}
 	}(inspectCh)
 
 	return inspectCh
@@ -85,30 +178,49 @@ func (sh *Shrinker) cleaner(ctx context.Context, done func(), removeCh chan *rem
 
 	for {
 		select {
-		case obj := <-removeCh:
-			if sh.verboseOutput {
-				log.Printf("removing: %s\n", obj.fullpath)
+		case obj, isOpen := <-removeCh:
+			if !isOpen {
+				done()
+				return
+			}
+
+			if obj.match != nil {
+				sh.logger.Debug("removing", "path", obj.fullpath, "match", obj.match)
+			} else {
+				sh.logger.Debug("removing", "path", obj.fullpath)
 			}
 
 			if obj.isDir {
-				stat, err = fsManager.Stat(obj.fullpath, true)
+				stat, err = sh.fs.Stat(obj.fullpath, true)
 			} else {
-				stat, err = fsManager.Stat(obj.fullpath, false)
+				stat, err = sh.fs.Stat(obj.fullpath, false)
 			}
 
 			if err != nil {
-				if sh.verboseOutput {
-					log.Printf("ERROR: %s\n", err)
-				}
+				sh.logger.Warn("fail stat", "path", obj.fullpath, "error", err)
+				sh.observer.OnError(&ErrorEvent{Path: obj.fullpath, Err: err})
+				sh.cache.addError(obj.fullpath)
 				continue
 			}
 
-			if err = fsManager.RemoveAll(obj.fullpath); err != nil {
-				if sh.verboseOutput {
-					log.Printf("ERROR: %s\n", err)
-				}
+			if err = sh.fs.RemoveAll(obj.fullpath); err != nil {
+				sh.logger.Warn("fail remove", "path", obj.fullpath, "error", err)
+				sh.observer.OnError(&ErrorEvent{Path: obj.fullpath, Err: err})
+				sh.cache.addError(obj.fullpath)
 				continue
 			}
+
+			sh.cache.addRemoved(obj.fullpath, stat)
+
+			sh.ruleStats.AddRemoved(obj.match, stat)
+			sh.progress.AddRemoved(stat.Size(), stat.FilesCount())
+			sh.observer.OnRemove(&RemoveEvent{
+				Path:       obj.fullpath,
+				IsDir:      obj.isDir,
+				Match:      obj.match,
+				Size:       stat.Size(),
+				FilesCount: stat.FilesCount(),
+			})
 			statsCh <- stat
 		case <-ctx.Done():
 			done()
@@ -165,42 +277,113 @@ func (sh *Shrinker) runStatGrabber(ctx context.Context, statsCh chan *FileStat)
 	return resCh
 }
 
-func (sh *Shrinker) fileFilterCallback(passCh chan *removeObjInfo) func(string, FileInfoI) error {
+func (sh *Shrinker) fileFilterCallback(ctx context.Context, passCh chan *removeObjInfo) func(string, FileInfoI) error {
 	return func(osPathname string, de FileInfoI) error {
-		isProcessable, err := sh.filter.Check(de)
-		if isProcessable {
+		if sh.protection(osPathname) != "" {
+			sh.observer.OnSkip(&SkipEvent{Path: osPathname, IsDir: de.IsDir(), Reason: SkipProtected})
+			return NotProcessError
+		}
+
+		sh.progress.AddScanned()
+
+		match := sh.match(ctx, osPathname, de)
+		if match.Removes() {
+			sh.ruleStats.AddMatch(match)
+			if !sh.observer.OnMatch(&MatchEvent{Path: osPathname, IsDir: de.IsDir(), Match: match}) {
+				sh.logger.Debug("keeping", "path", osPathname, "reason", SkipVetoed)
+				sh.observer.OnSkip(&SkipEvent{Path: osPathname, IsDir: de.IsDir(), Reason: SkipVetoed, Match: match})
+				return NotProcessError
+			}
+			sh.progress.AddMatched()
+
 			ff := removeObjInfo{
 				isDir:    de.IsDir(),
 				filename: de.Name(),
 				fullpath: osPathname,
+				match:    match,
 			}
 			passCh <- &ff
+
+			if de.IsDir() {
+				return SkipDirError // whole directory is removed, so no need to walk inside it
+			}
+			return nil
 		}
 
-		if err != nil {
-			return err
+		if match.Excludes() {
+			sh.ruleStats.AddMatch(match)
+			sh.logger.Debug("keeping", "path", osPathname, "match", match)
+			sh.observer.OnSkip(&SkipEvent{Path: osPathname, IsDir: de.IsDir(), Reason: SkipExcluded, Match: match})
+			return ExcludeError
 		}
-		return nil
+
+		if de.IsDir() && sh.cache != nil {
+			if state := sh.packageState(osPathname); state != nil && sh.cache.visit(osPathname, state) {
+				sh.logger.Debug("keeping", "path", osPathname, "reason", SkipCached, "package", state.ID)
+				sh.observer.OnSkip(&SkipEvent{Path: osPathname, IsDir: true, Reason: SkipCached})
+				if filepath.Base(filepath.Dir(osPathname)) != pnpmStoreDirName {
+					sh.cache.addNested(filepath.Join(osPathname, nodeModulesDirName))
+				}
+				return SkipDirError
+			}
+		}
+		return NotProcessError
 	}
 }
 
 func (sh *Shrinker) fileFilterErrCallback(osPathname string, err error) ErrorAction {
 	// TODO: more informative logging about errors
-	if err == SkipDirError {
+	if err == SkipDirError || err == ExcludeError {
 		return SkipNode
 	}
 
-	if sh.verboseOutput {
-		log.Printf("ERROR: %s\n", err)
-	}
+	sh.logger.Warn("skip entry", "path", osPathname, "error", err)
+	sh.observer.OnError(&ErrorEvent{Path: osPathname, Err: err})
+	sh.cache.addError(osPathname)
 	return SkipNode
 }
 
-func (sh *Shrinker) layoutPrinterWrapper(checkPath string) chan *FileStat {
+// Returns why path must never be removed: files written by shrinker itself are protected.
+// Empty string is returned for other pathes
+func (sh *Shrinker) protection(entryPath string) string {
+	if sh.noticesFile != "" && sh.samePath(entryPath, sh.noticesFile) {
+		return "file with collected notices is never removed"
+	}
+	if sh.cacheFile != "" && sh.samePath(entryPath, sh.cacheFile) {
+		return "state cache is never removed"
+	}
+	return ""
+}
+
+// Compares pathes after resolving relative ones from working directory, so "./x" and "/cwd/x" are the same
+func (sh *Shrinker) samePath(path1, path2 string) bool {
+	if filepath.Base(path1) != filepath.Base(path2) {
+		return false // cheap check for most of walked entries
+	}
+	return sh.absPath(path1) == sh.absPath(path2)
+}
+
+func (sh *Shrinker) absPath(p string) string {
+	if !filepath.IsAbs(p) {
+		if wd, err := sh.fs.Getwd(); err == nil {
+			p = filepath.Join(wd, p)
+		}
+	}
+	return filepath.Clean(p)
+}
+
+func outputOrDefault(output io.Writer) io.Writer {
+	if output == nil {
+		return os.Stdout
+	}
+	return output
+}
+
+func (sh *Shrinker) layoutPrinterWrapper(ctx context.Context, checkPath string) chan *FileStat {
 	ch := make(chan *FileStat)
 
 	go func(ch chan *FileStat) {
-		_ = sh.layoutPrinter(checkPath, "", ch)
+		_ = sh.layoutPrinter(ctx, checkPath, "", ch)
 
 		close(ch)
 	}(ch)
@@ -208,20 +391,35 @@ func (sh *Shrinker) layoutPrinterWrapper(checkPath string) chan *FileStat {
 	return ch
 }
 
-func (sh *Shrinker) layoutPrinter(checkPath string, tabPassed string, statsCh chan *FileStat) error {
-	files, err := ioutil.ReadDir(checkPath)
+func (sh *Shrinker) layoutPrinter(ctx context.Context, checkPath string, tabPassed string, statsCh chan *FileStat) error {
+	files, err := sh.fs.ReadDir(checkPath)
 	if err != nil {
 		return err
 	}
 
-	filteredFiles := make([]string, 0)
+	processedFiles := make(map[string]*Match)
+	excludedFiles := make(map[string]struct{})
 	for _, file := range files {
-		isProcess, _ := sh.filter.Check(NewFileInfoFromOsFile(file))
-		if isProcess {
-			filteredFiles = append(filteredFiles, file.Name())
+		fullpath := path.Join(checkPath, file.Name())
+		if sh.protection(fullpath) != "" {
+			excludedFiles[file.Name()] = struct{}{}
+			sh.observer.OnSkip(&SkipEvent{Path: fullpath, IsDir: file.IsDir(), Reason: SkipProtected})
+			continue
+		}
+		match := sh.match(ctx, fullpath, NewFileInfoFromOsFile(file))
+		if match.Removes() {
+			sh.ruleStats.AddMatch(match)
+			if sh.observer.OnMatch(&MatchEvent{Path: fullpath, IsDir: file.IsDir(), Match: match}) {
+				processedFiles[file.Name()] = match
+			} else {
+				sh.observer.OnSkip(&SkipEvent{Path: fullpath, IsDir: file.IsDir(), Reason: SkipVetoed, Match: match})
+			}
+		} else if match.Excludes() {
+			excludedFiles[file.Name()] = struct{}{}
+			sh.ruleStats.AddMatch(match)
+			sh.observer.OnSkip(&SkipEvent{Path: fullpath, IsDir: file.IsDir(), Reason: SkipExcluded, Match: match})
 		}
 	}
-	processedFiles := sliceToMap(filteredFiles)
 
 	var tabToAdd, tabToPass, logLine string
 	var printName, printFileSize interface{}
@@ -241,14 +439,14 @@ func (sh *Shrinker) layoutPrinter(checkPath string, tabPassed string, statsCh ch
 
 		tabToPass = tabPassed + tabToPass
 
-		_, isFileInProcess := processedFiles[file.Name()]
+		match, isFileInProcess := processedFiles[file.Name()]
 		if file.IsDir() {
 			if isFileInProcess {
 				printName = color.Green(printName)
 			} else {
 				printName = color.Yellow(printName)
 			}
-			stat, err := fsManager.Stat(path.Join(checkPath, file.Name()), true)
+			stat, err := sh.fs.Stat(path.Join(checkPath, file.Name()), true)
 			if err == nil {
 				fileSize = stat.Size()
 				fileStat = stat
@@ -273,16 +471,18 @@ func (sh *Shrinker) layoutPrinter(checkPath string, tabPassed string, statsCh ch
 		}
 
 		logLine = fmt.Sprintf("%v%v%v (%v)\n", tabPassed, tabToAdd, printName, printFileSize)
-		log.Println(logLine)
+		fmt.Fprintln(sh.output, logLine)
 
 		if isFileInProcess {
+			sh.ruleStats.AddRemoved(match, fileStat)
 			statsCh <- fileStat
 		}
 
-		// skip directories that matched by name
-		if file.IsDir() && !isFileInProcess {
+		// skip directories that matched by name, excluded ones are protected with all nested entries like in Clean
+		_, isExcluded := excludedFiles[file.Name()]
+		if file.IsDir() && !isFileInProcess && !isExcluded {
 			nextDirPath := fmt.Sprintf("%v/%v", checkPath, file.Name())
-			_ = sh.layoutPrinter(nextDirPath, tabToPass, statsCh)
+			_ = sh.layoutPrinter(ctx, nextDirPath, tabToPass, statsCh)
 		}
 	}
 
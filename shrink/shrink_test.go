@@ -3,29 +3,16 @@ package shrink
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/icecream78/node_shrinker/fs"
-
-	. "github.com/icecream78/node_shrinker/walker"
 	"github.com/stretchr/testify/assert"
 )
 
-type walkerStub struct {
-	testPathes map[string]string
-}
-
-func (w *walkerStub) SetFileStructure(pathes map[string]string) {
-	w.testPathes = pathes
-}
-
-func (w *walkerStub) Walk(filepath string, callback WalkFunc, errCallback WalkErrFunc) error {
-	for path := range w.testPathes {
-		_ = callback(path, &FileInfo{})
-	}
-	return nil
-}
-
 func TestStatGrabberFunc(t *testing.T) {
 	testCases := []struct {
 		alias string
@@ -63,20 +50,190 @@ func TestStatGrabberFunc(t *testing.T) {
 	}
 }
 
-type testFileInfo struct {
-	name      string
-	isDir     bool
-	isRegular bool
+func newMemShrinker(t *testing.T, memFS *fs.MemFS, cfg *Config) *Shrinker {
+	cfg.CheckPath = "/project/node_modules"
+	cfg.ConcurentLimit = 2
+	cfg.FS = memFS
+	cfg.Walker = memFS
+	cfg.IncludeNames = []string{"test", "README.md"}
+	cfg.RemoveFileExt = []string{".ts"}
+	cfg.Output = ioutil.Discard
+
+	sh, err := NewShrinker(cfg)
+	assert.Nil(t, err)
+	return sh
+}
+
+func newMemTree() fs.MemTree {
+	return fs.MemTree{
+		"node_modules/a/index.js":         fs.MemFile("module.exports = 1"),
+		"node_modules/a/index.d.ts":       fs.MemFile("export {}"),
+		"node_modules/a/README.md":        fs.MemFile("# a"),
+		"node_modules/a/test/a.spec.js":   fs.MemFile("0123456789"),
+		"node_modules/a/test/b.spec.js":   fs.MemFile("0123456789"),
+		"node_modules/b/keep/x.js":        fs.MemFile("x"),
+		"node_modules/b/lib/main.ts":      fs.MemFile("locked"),
+		"node_modules/b/lib":              {IsDir: true, Denied: true},
+		"node_modules/.bin/a":             fs.MemSymlink("../a/index.js"),
+		"node_modules/.package-lock.json": fs.MemFile("{}"),
+	}
+}
+
+func TestCleanWithMemFSFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newMemTree())
+
+	sh := newMemShrinker(t, memFS, &Config{})
+	stats := sh.Clean(context.TODO())
+
+	// a/test is removed as a whole, locked b/lib isn't walked
+	assert.Equal(t, int64(len("export {}")+len("# a")+20), stats.Size())
+	assert.Equal(t, int64(4), stats.FilesCount())
+	assert.Equal(t, []string{
+		"node_modules",
+		"node_modules/.bin",
+		"node_modules/.bin/a",
+		"node_modules/.package-lock.json",
+		"node_modules/a",
+		"node_modules/a/index.js",
+		"node_modules/b",
+		"node_modules/b/keep",
+		"node_modules/b/keep/x.js",
+		"node_modules/b/lib",
+		"node_modules/b/lib/main.ts",
+	}, memFS.Paths())
+}
+
+func TestDryRunWithMemFSFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newMemTree())
+	before := memFS.Paths()
+
+	sh := newMemShrinker(t, memFS, &Config{DryRun: true})
+	first := sh.DryRun(context.TODO())
+	second := sh.DryRun(context.TODO())
+
+	assert.Equal(t, before, memFS.Paths())
+	assert.Equal(t, first, second)
+	assert.Equal(t, int64(len("export {}")+len("# a")+20), first.Size())
+}
+
+func TestProtectedFilesFunc(t *testing.T) {
+	testCases := []struct {
+		alias         string
+		cfg           Config
+		protectedPath string
+		expectedSize  int64
+	}{
+		{
+			alias:         "relative notices file",
+			cfg:           Config{NoticesFile: "./node_modules/a/README.md"},
+			protectedPath: "/project/node_modules/a/README.md",
+			expectedSize:  int64(len("export {}") + 20),
+		},
+		{
+			alias:         "unclean cache file",
+			cfg:           Config{CacheFile: "node_modules/b/../a/index.d.ts"},
+			protectedPath: "/project/node_modules/a/index.d.ts",
+			expectedSize:  int64(len("# a") + 20),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias+" in dry run", func(t *testing.T) {
+			cfg := tc.cfg
+			sh := newMemShrinker(t, fs.NewMemFS("/project", newMemTree()), &cfg)
+
+			stats := sh.DryRun(context.TODO())
+			assert.Equal(t, tc.expectedSize, stats.Size())
+			assert.Equal(t, int64(3), stats.FilesCount())
+		})
+
+		t.Run(tc.alias+" in clean", func(t *testing.T) {
+			cfg := tc.cfg
+			memFS := fs.NewMemFS("/project", newMemTree())
+			sh := newMemShrinker(t, memFS, &cfg)
+
+			stats := sh.Clean(context.TODO())
+			assert.Equal(t, tc.expectedSize, stats.Size())
+			assert.Equal(t, int64(3), stats.FilesCount())
+			_, err := memFS.Lstat(tc.protectedPath)
+			assert.Nil(t, err)
+		})
+	}
 }
 
-func (tfi testFileInfo) Name() string {
-	return tfi.name
+func TestNewShrinkerWithMemFSFunc(t *testing.T) {
+	memFS := fs.NewMemFS("/project", newMemTree())
+
+	_, err := NewShrinker(&Config{CheckPath: "/project/missing", FS: memFS, Walker: memFS})
+	assert.Equal(t, NotExistError, err)
 }
 
-func (tfi testFileInfo) IsDir() bool {
-	return tfi.isDir
+// Run with -race: shrinkers must not share any state
+func TestConcurrentShrinkersFunc(t *testing.T) {
+	const shrinkersCount = 8
+
+	results := make([]*fs.FileStat, shrinkersCount)
+	memFSs := make([]*fs.MemFS, shrinkersCount)
+
+	var wg sync.WaitGroup
+	for i := 0; i < shrinkersCount; i++ {
+		memFSs[i] = fs.NewMemFS("/project", newMemTree())
+		dryRun := i%2 == 0
+		sh := newMemShrinker(t, memFSs[i], &Config{DryRun: dryRun})
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if dryRun {
+				results[i] = sh.DryRun(context.TODO())
+			} else {
+				results[i] = sh.Clean(context.TODO())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	want := newMemShrinker(t, fs.NewMemFS("/project", newMemTree()), &Config{DryRun: true}).DryRun(context.TODO())
+	for i, stats := range results {
+		assert.Equal(t, want, stats, fmt.Sprintf("Shrinker: %d", i))
+		if i%2 == 0 {
+			assert.Contains(t, memFSs[i].Paths(), "node_modules/a/test")
+		} else {
+			assert.NotContains(t, memFSs[i].Paths(), "node_modules/a/test")
+		}
+	}
 }
 
-func (tfi testFileInfo) IsRegular() bool {
-	return tfi.isRegular
+func TestShrinkersOwnWalkersFunc(t *testing.T) {
+	root, err := ioutil.TempDir("", "shrinkers")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"dry/test", "clean/test"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(root, dir, "a.js"), []byte("data"), 0644))
+	}
+
+	dry, err := NewShrinker(&Config{DryRun: true, CheckPath: filepath.Join(root, "dry"), IncludeNames: []string{"test"}, Output: ioutil.Discard})
+	assert.Nil(t, err)
+	clean, err := NewShrinker(&Config{CheckPath: filepath.Join(root, "clean"), IncludeNames: []string{"test"}})
+	assert.Nil(t, err)
+	assert.False(t, dry.walker == clean.walker)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		dry.DryRun(context.TODO())
+	}()
+	go func() {
+		defer wg.Done()
+		clean.Clean(context.TODO())
+	}()
+	wg.Wait()
+
+	_, err = os.Stat(filepath.Join(root, "dry/test"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(root, "clean/test"))
+	assert.True(t, os.IsNotExist(err))
 }
@@ -0,0 +1,201 @@
+package shrink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+// version of snapshot format, increased on incompatible changes
+const SnapshotFormatVersion int = 1
+
+// files with this size or bigger are listed in snapshot by default
+const DefaultLargeFileSize int64 = 1024 * 1024
+
+var UnsupportedSnapshotError error = errors.New("unsupported snapshot format version")
+
+type SnapshotConfig struct {
+	VerboseOutput bool
+	Logger        Logger // nil means text output to stderr
+	Path          string
+	LargeFileSize int64  // files with this size or bigger are listed in snapshot
+	FS            FS     // OS file system by default
+	Walker        Walker // directory walker of OS file system by default
+}
+
+// PackageSnapshot sums all installed copies of package
+type PackageSnapshot struct {
+	Name       string   `json:"name"` // empty for files outside of node_modules
+	Versions   []string `json:"versions"`
+	Size       int64    `json:"size"`
+	FilesCount int64    `json:"files"`
+}
+
+type FileSnapshot struct {
+	Path string `json:"path"` // relative to snapshot root
+	Size int64  `json:"size"`
+}
+
+// Snapshot is a summary of directory tree, which can be saved and compared with another one later
+type Snapshot struct {
+	FormatVersion int                `json:"format_version"`
+	Root          string             `json:"root"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Size          int64              `json:"size"`
+	FilesCount    int64              `json:"files"`
+	LargeFileSize int64              `json:"large_file_size"`
+	Packages      []*PackageSnapshot `json:"packages"`    // sorted by name
+	LargeFiles    []*FileSnapshot    `json:"large_files"` // sorted by path
+}
+
+// Snapshotter walks directory tree and summarizes it by packages
+type Snapshotter struct {
+	fs            FS
+	logger        Logger
+	path          string
+	largeFileSize int64
+	walker        Walker
+}
+
+func NewSnapshotter(cfg *SnapshotConfig) (*Snapshotter, error) {
+	fs := fsOrDefault(cfg.FS)
+	if !pathExists(fs, cfg.Path) {
+		return nil, NotExistError
+	}
+
+	largeFileSize := cfg.LargeFileSize
+	if largeFileSize <= 0 {
+		largeFileSize = DefaultLargeFileSize
+	}
+
+	return &Snapshotter{
+		fs:            fs,
+		logger:        loggerOrDefault(cfg.Logger, cfg.VerboseOutput),
+		path:          cfg.Path,
+		largeFileSize: largeFileSize,
+		walker:        walkerOrDefault(cfg.Walker, false),
+	}, nil
+}
+
+func (sn *Snapshotter) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		FormatVersion: SnapshotFormatVersion,
+		Root:          sn.path,
+		CreatedAt:     time.Now().UTC(),
+		LargeFileSize: sn.largeFileSize,
+		LargeFiles:    make([]*FileSnapshot, 0),
+	}
+	packages := make(map[string]*PackageSnapshot)
+	versions := make(map[string]map[string]struct{})
+
+	err := sn.walker.Walk(sn.path, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !de.IsRegular() {
+			return nil
+		}
+
+		stat, err := sn.fs.Stat(osPathname, false)
+		if err != nil {
+			sn.logger.Warn("fail stat", "path", osPathname, "error", err)
+			return nil
+		}
+
+		name := packageNameFromPath(osPathname)
+		pkg, exists := packages[name]
+		if !exists {
+			pkg = &PackageSnapshot{Name: name}
+			packages[name] = pkg
+			versions[name] = make(map[string]struct{})
+		}
+
+		pkg.Size += stat.Size()
+		pkg.FilesCount++
+		snapshot.Size += stat.Size()
+		snapshot.FilesCount++
+
+		if de.Name() == PackageManifestName && isPackageRoot(filepath.Dir(osPathname)) {
+			if version := sn.packageVersion(osPathname); version != "" {
+				versions[name][version] = struct{}{}
+			}
+		}
+
+		if stat.Size() >= sn.largeFileSize {
+			relPath, err := filepath.Rel(sn.path, osPathname)
+			if err != nil {
+				relPath = osPathname
+			}
+			snapshot.LargeFiles = append(snapshot.LargeFiles, &FileSnapshot{Path: filepath.ToSlash(relPath), Size: stat.Size()})
+		}
+		return nil
+	}, sn.walkErrCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Packages = make([]*PackageSnapshot, 0, len(packages))
+	for name, pkg := range packages {
+		pkg.Versions = sortedSet(versions[name])
+		sort.SliceStable(pkg.Versions, func(i, j int) bool {
+			return compareVersions(pkg.Versions[i], pkg.Versions[j]) < 0
+		})
+		snapshot.Packages = append(snapshot.Packages, pkg)
+	}
+	sort.Slice(snapshot.Packages, func(i, j int) bool {
+		return snapshot.Packages[i].Name < snapshot.Packages[j].Name
+	})
+	sort.Slice(snapshot.LargeFiles, func(i, j int) bool {
+		return snapshot.LargeFiles[i].Path < snapshot.LargeFiles[j].Path
+	})
+
+	return snapshot, nil
+}
+
+func (sn *Snapshotter) packageVersion(manifestPath string) string {
+	data, err := sn.fs.ReadFile(manifestPath)
+	if err != nil {
+		return ""
+	}
+
+	manifest, err := parsePackageManifest(data)
+	if err != nil {
+		return ""
+	}
+	return manifest.Version
+}
+
+func (sn *Snapshotter) walkErrCallback(osPathname string, err error) ErrorAction {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return Halt
+	}
+
+	sn.logger.Warn("skip entry", "path", osPathname, "error", err)
+	return SkipNode
+}
+
+func (s *Snapshot) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, err
+	}
+
+	if snapshot.FormatVersion != SnapshotFormatVersion {
+		return nil, UnsupportedSnapshotError
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,97 @@
+package shrink
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotFunc(t *testing.T) {
+	root, err := ioutil.TempDir("", "snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"index.js":                                   "root",
+		"node_modules/a/package.json":                `{"name":"a","version":"1.10.0"}`,
+		"node_modules/a/big.js":                      strings.Repeat("x", 100),
+		"node_modules/b/package.json":                `{"name":"b","version":"1.0.0"}`,
+		"node_modules/b/node_modules/a/package.json": `{"name":"a","version":"1.9.0"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	snapshotter, err := NewSnapshotter(&SnapshotConfig{Path: root, LargeFileSize: 100})
+	assert.Nil(t, err)
+
+	snapshot, err := snapshotter.Snapshot(context.Background())
+	assert.Nil(t, err)
+
+	assert.Equal(t, int64(5), snapshot.FilesCount)
+	assert.Len(t, snapshot.Packages, 3)
+	assert.Equal(t, "", snapshot.Packages[0].Name)
+	assert.Equal(t, "a", snapshot.Packages[1].Name)
+	assert.Equal(t, []string{"1.9.0", "1.10.0"}, snapshot.Packages[1].Versions)
+	assert.Equal(t, int64(3), snapshot.Packages[1].FilesCount)
+	assert.Equal(t, []*FileSnapshot{{Path: "node_modules/a/big.js", Size: 100}}, snapshot.LargeFiles)
+
+	var buf bytes.Buffer
+	assert.Nil(t, snapshot.Write(&buf))
+	restored, err := ReadSnapshot(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, snapshot.Packages, restored.Packages)
+
+	_, err = ReadSnapshot(strings.NewReader(`{"format_version": 100}`))
+	assert.Equal(t, UnsupportedSnapshotError, err)
+}
+
+func TestDiffSnapshotsFunc(t *testing.T) {
+	before := &Snapshot{
+		Size:       300,
+		FilesCount: 6,
+		Packages: []*PackageSnapshot{
+			{Name: "kept", Versions: []string{"1.0.0"}, Size: 100, FilesCount: 2},
+			{Name: "removed", Versions: []string{"1.0.0"}, Size: 50, FilesCount: 1},
+			{Name: "updated", Versions: []string{"1.0.0"}, Size: 100, FilesCount: 2},
+			{Name: "shrunk", Versions: []string{"2.0.0"}, Size: 50, FilesCount: 1},
+		},
+		LargeFiles: []*FileSnapshot{{Path: "kept/big.js", Size: 80}},
+	}
+	after := &Snapshot{
+		Size:       510,
+		FilesCount: 8,
+		Packages: []*PackageSnapshot{
+			{Name: "added", Versions: []string{"1.0.0"}, Size: 200, FilesCount: 2},
+			{Name: "kept", Versions: []string{"1.0.0"}, Size: 100, FilesCount: 2},
+			{Name: "shrunk", Versions: []string{"2.0.0"}, Size: 10, FilesCount: 1},
+			{Name: "updated", Versions: []string{"1.1.0"}, Size: 200, FilesCount: 3},
+		},
+		LargeFiles: []*FileSnapshot{
+			{Path: "added/small.js", Size: 60},
+			{Path: "added/big.js", Size: 120},
+			{Path: "kept/big.js", Size: 80},
+		},
+	}
+
+	diff := DiffSnapshots(before, after, 100)
+
+	assert.Equal(t, int64(210), diff.SizeDelta())
+	assert.Equal(t, int64(2), diff.FilesDelta())
+	assert.Equal(t, []*PackageSnapshot{after.Packages[0]}, diff.Added)
+	assert.Equal(t, []*PackageSnapshot{before.Packages[1]}, diff.Removed)
+	assert.Len(t, diff.Changed, 2)
+	assert.Equal(t, "updated", diff.Changed[0].Name)
+	assert.Equal(t, int64(100), diff.Changed[0].SizeDelta())
+	assert.Equal(t, "shrunk", diff.Changed[1].Name)
+	assert.Equal(t, int64(-40), diff.Changed[1].SizeDelta())
+	assert.Equal(t, []*FileSnapshot{{Path: "added/big.js", Size: 120}}, diff.NewLargeFiles)
+}
@@ -0,0 +1,136 @@
+package shrink
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+type SweepConfig struct {
+	VerboseOutput bool
+	Logger        Logger // nil means text output to stderr
+	RootPath      string
+	FS            FS     // OS file system by default
+	Walker        Walker // ordered directory walker of OS file system by default
+}
+
+// Project is a node_modules directory found by Sweeper
+type Project struct {
+	Path       string // path to node_modules directory
+	Size       int64
+	FilesCount int64
+	ModTime    time.Time // last modification time of package.json near node_modules or node_modules itself if there is no package.json
+}
+
+// Checks is project wasn't modified during provided duration
+func (p *Project) IsStale(olderThan time.Duration, now time.Time) bool {
+	return now.Sub(p.ModTime) > olderThan
+}
+
+// Sweeper searches node_modules directories of many projects and removes them as a whole
+type Sweeper struct {
+	fs       FS
+	logger   Logger
+	rootPath string
+	walker   Walker
+}
+
+func NewSweeper(cfg *SweepConfig) (*Sweeper, error) {
+	fs := fsOrDefault(cfg.FS)
+	if !pathExists(fs, cfg.RootPath) {
+		return nil, NotExistError
+	}
+
+	return &Sweeper{
+		fs:       fs,
+		logger:   loggerOrDefault(cfg.Logger, cfg.VerboseOutput),
+		rootPath: cfg.RootPath,
+		walker:   walkerOrDefault(cfg.Walker, true), // keep order for stable output
+	}, nil
+}
+
+// Returns all node_modules directories under root path. Nested node_modules directories are not walked
+func (sw *Sweeper) Projects(ctx context.Context) ([]*Project, error) {
+	projects := make([]*Project, 0)
+
+	err := sw.walker.Walk(sw.rootPath, func(osPathname string, de FileInfoI) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !de.IsDir() || de.Name() != nodeModulesDirName {
+			return nil
+		}
+
+		project, err := sw.inspectProject(osPathname)
+		if err != nil {
+			sw.logger.Warn("fail inspect project", "path", osPathname, "error", err)
+		} else {
+			projects = append(projects, project)
+		}
+		return SkipDirError
+	}, sw.walkErrCallback)
+
+	return projects, err
+}
+
+func (sw *Sweeper) inspectProject(nodeModulesPath string) (*Project, error) {
+	stat, err := sw.fs.Stat(nodeModulesPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	modTimeStat, err := sw.fs.Stat(filepath.Join(filepath.Dir(nodeModulesPath), PackageManifestName), false)
+	if err != nil {
+		if modTimeStat, err = sw.fs.Stat(nodeModulesPath, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Project{
+		Path:       nodeModulesPath,
+		Size:       stat.Size(),
+		FilesCount: stat.FilesCount(),
+		ModTime:    modTimeStat.ModTime(),
+	}, nil
+}
+
+func (sw *Sweeper) walkErrCallback(osPathname string, err error) ErrorAction {
+	if err == SkipDirError {
+		return SkipNode
+	}
+
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return Halt
+	}
+
+	sw.logger.Warn("skip entry", "path", osPathname, "error", err)
+	return SkipNode
+}
+
+// Removes node_modules directories of provided projects
+func (sw *Sweeper) Sweep(ctx context.Context, projects []*Project) (stats *FileStat) {
+	var removedCount int64
+	var removedSize int64
+
+	for _, project := range projects {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sw.logger.Debug("removing", "path", project.Path)
+
+		if err := sw.fs.RemoveAll(project.Path); err != nil {
+			sw.logger.Warn("fail remove", "path", project.Path, "error", err)
+			continue
+		}
+
+		removedCount += project.FilesCount
+		removedSize += project.Size
+	}
+
+	return NewFileStat("result", "result", removedSize, removedCount)
+}
@@ -0,0 +1,100 @@
+package shrink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/icecream78/node_shrinker/mocks"
+	. "github.com/icecream78/node_shrinker/walker"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// walks through list of entries in provided order and respects SkipDirError like real walker does
+type orderedWalkerStub struct {
+	entries []*fileTestStub
+	pathes  []string
+}
+
+func (w *orderedWalkerStub) Add(path string, isFile bool) *orderedWalkerStub {
+	parts := strings.Split(path, "/")
+	w.entries = append(w.entries, newFileTestStub(parts[len(parts)-1], isFile))
+	w.pathes = append(w.pathes, path)
+	return w
+}
+
+func (w *orderedWalkerStub) Walk(filepath string, callback WalkFunc, errCallback WalkErrFunc) error {
+	skipPrefix := ""
+	for i, path := range w.pathes {
+		if skipPrefix != "" && strings.HasPrefix(path, skipPrefix) {
+			continue
+		}
+
+		if err := callback(path, w.entries[i]); err != nil {
+			if errCallback(path, err) == Halt {
+				return err
+			}
+			skipPrefix = path + "/"
+		}
+	}
+	return nil
+}
+
+func TestSweeperProjectsFunc(t *testing.T) {
+	osMock := new(mocks.FS)
+
+	osMock.On("Stat", mock.Anything, true).Return(fs.NewFileStat("node_modules", "node_modules", 1024, 2), nil)
+	osMock.On("Stat", "/p/a/package.json", false).Return(fs.NewFileStat("package.json", "/p/a/package.json", 1, 1), nil)
+	osMock.On("Stat", "/p/b/package.json", false).Return(nil, fmt.Errorf("not exist"))
+	osMock.On("Stat", "/p/b/node_modules", false).Return(fs.NewFileStat("node_modules", "/p/b/node_modules", 1, 1), nil)
+
+	walker := (&orderedWalkerStub{}).
+		Add("/p", false).
+		Add("/p/a", false).
+		Add("/p/a/package.json", true).
+		Add("/p/a/node_modules", false).
+		Add("/p/a/node_modules/x", false).
+		Add("/p/a/node_modules/x/node_modules", false).
+		Add("/p/b", false).
+		Add("/p/b/node_modules", false).
+		Add("/p/b/src", false).
+		Add("/p/b/src/node_modules", true) // file, not a directory
+
+	sw := &Sweeper{fs: osMock, rootPath: "/p", walker: walker, logger: loggerOrDefault(nil, false)}
+	projects, err := sw.Projects(context.TODO())
+	assert.Nil(t, err)
+
+	pathes := make([]string, 0)
+	for _, project := range projects {
+		pathes = append(pathes, project.Path)
+		assert.Equal(t, int64(1024), project.Size)
+		assert.Equal(t, int64(2), project.FilesCount)
+	}
+	assert.Equal(t, []string{"/p/a/node_modules", "/p/b/node_modules"}, pathes)
+}
+
+func TestProjectIsStaleFunc(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		alias   string
+		modTime time.Time
+		want    bool
+	}{
+		{"Test recently modified project", now.Add(-24 * time.Hour), false},
+		{"Test old project", now.Add(-31 * 24 * time.Hour), true},
+		{"Test project without modification time", time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			project := &Project{ModTime: tc.modTime}
+			assert.Equal(t, tc.want, project.IsStale(30*24*time.Hour, now))
+		})
+	}
+}
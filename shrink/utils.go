@@ -1,9 +1,15 @@
 package shrink
 
 import (
-	"log"
+	"fmt"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
 )
 
 func sliceToMap(sl ...[]string) map[string]struct{} {
@@ -17,11 +23,26 @@ func sliceToMap(sl ...[]string) map[string]struct{} {
 	return m
 }
 
-func pathExists(path string) bool {
-	_, err := fsManager.Stat(path, false)
+func pathExists(fs FS, path string) bool {
+	_, err := fs.Stat(path, false)
 	return !os.IsNotExist(err)
 }
 
+func fsOrDefault(fs FS) FS {
+	if fs != nil {
+		return fs
+	}
+	return NewFS()
+}
+
+// Walker of OS file system is created for every user, because keepOrder setting differs between them
+func walkerOrDefault(walker Walker, keepOrder bool) Walker {
+	if walker != nil {
+		return walker
+	}
+	return NewDirWalker(keepOrder)
+}
+
 func isStringPattern(input string) bool {
 	for i := 0; i < len(input); i++ {
 		switch input[i] {
@@ -46,16 +67,43 @@ func devidePatternsFromRegularNames(input []string) (patterns []string, regular
 	return
 }
 
+// Compiles all valid regular expressions. Invalid ones are skipped and listed in returned error
 func compileRegExpList(regExpList []string) ([]*regexp.Regexp, error) {
 	regList := make([]*regexp.Regexp, 0)
+	invalid := make([]string, 0)
 	for i := 0; i < len(regExpList); i++ {
 		cmp, err := regexp.Compile(regExpList[i])
 		if err != nil {
-			log.Printf("Error compile regular expression: %s\n", regExpList[i])
-
+			invalid = append(invalid, err.Error())
 			continue
 		}
 		regList = append(regList, cmp)
 	}
+
+	if len(invalid) != 0 {
+		return regList, fmt.Errorf("invalid regular expressions: %s", strings.Join(invalid, "; "))
+	}
 	return regList, nil
 }
+
+var durationUnits map[string]time.Duration = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// Parses duration like time.ParseDuration does, but also supports days (30d) and weeks (2w)
+func ParseDuration(input string) (time.Duration, error) {
+	for suffix, unit := range durationUnits {
+		if !strings.HasSuffix(input, suffix) {
+			continue
+		}
+
+		count, err := strconv.ParseFloat(strings.TrimSuffix(input, suffix), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", input)
+		}
+		return time.Duration(count * float64(unit)), nil
+	}
+
+	return time.ParseDuration(input)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/icecream78/node_shrinker/fs"
 
@@ -15,14 +16,13 @@ import (
 func TestPathExists(t *testing.T) {
 	osMock := new(mocks.FS)
 
-	fsManager = osMock
 	osMock.On("Stat", "/test1", false).Return(fs.NewFileStat("/test1", "/test1", 1, 1), nil)
 	osMock.On("Stat", "/test13", false).Return(nil, os.ErrNotExist)
 	osMock.On("Stat", "/test14", false).Return(nil, os.ErrPermission)
 
-	assert.Equal(t, pathExists("/test1"), true)
-	assert.Equal(t, pathExists("/test14"), true)
-	assert.Equal(t, pathExists("/test13"), false)
+	assert.Equal(t, pathExists(osMock, "/test1"), true)
+	assert.Equal(t, pathExists(osMock, "/test14"), true)
+	assert.Equal(t, pathExists(osMock, "/test13"), false)
 
 	osMock.AssertExpectations(t)
 }
@@ -63,3 +63,27 @@ func TestCompileErrorFunc(t *testing.T) {
 	_, err := compileRegExpList(inputRegExp)
 	assert.NotNil(t, err, fmt.Sprintf("Input: %v", inputRegExp))
 }
+
+func TestParseDurationFunc(t *testing.T) {
+	testCases := []struct {
+		alias     string
+		input     string
+		want      time.Duration
+		wantError bool
+	}{
+		{"Test days", "30d", 30 * 24 * time.Hour, false},
+		{"Test fractional days", "1.5d", 36 * time.Hour, false},
+		{"Test weeks", "2w", 14 * 24 * time.Hour, false},
+		{"Test standard duration", "12h", 12 * time.Hour, false},
+		{"Test invalid days", "xd", 0, true},
+		{"Test invalid duration", "month", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			got, err := ParseDuration(tc.input)
+			assert.Equal(t, tc.wantError, err != nil, fmt.Sprintf("Input: %s, error: %v", tc.input, err))
+			assert.Equal(t, tc.want, got, fmt.Sprintf("Input: %s", tc.input))
+		})
+	}
+}
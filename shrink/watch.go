@@ -0,0 +1,228 @@
+package shrink
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	. "github.com/icecream78/node_shrinker/fs"
+	. "github.com/icecream78/node_shrinker/walker"
+)
+
+// virtual store of pnpm, every entry of it contains node_modules directory with one package
+const pnpmStoreDirName = ".pnpm"
+
+// WatchResult describes shrinking of packages changed by one install
+type WatchResult struct {
+	Packages []string // roots of shrunk packages
+	Stats    *FileStat
+}
+
+// Checks should directory be watched for installs. Watched are node_modules directories with
+// their scopes and packages, and entries of pnpm store. Inner directories of packages aren't
+// watched, because installs replace packages as a whole
+func IsWatchedDir(dir string) bool {
+	name := filepath.Base(dir)
+	parent := filepath.Base(filepath.Dir(dir))
+	grandParent := filepath.Base(filepath.Dir(filepath.Dir(dir)))
+
+	switch {
+	case name == nodeModulesDirName:
+		return true
+	case parent == nodeModulesDirName:
+		return true
+	case strings.HasPrefix(parent, "@") && grandParent == nodeModulesDirName:
+		return true
+	case parent == pnpmStoreDirName && grandParent == nodeModulesDirName:
+		return true
+	}
+	return false
+}
+
+// Returns root of package which owns path: node_modules/name, node_modules/@scope/name
+// or node_modules/.pnpm/name@version. Empty string for pathes outside of packages
+func packageRootFromPath(osPathname string) string {
+	parts := strings.Split(filepath.ToSlash(osPathname), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] != nodeModulesDirName {
+			continue
+		}
+		// node_modules inside of pnpm store entry belongs to the entry
+		if i >= 3 && parts[i-2] == pnpmStoreDirName && parts[i-3] == nodeModulesDirName {
+			continue
+		}
+
+		end := i + 2
+		name := parts[i+1]
+		if strings.HasPrefix(name, "@") || name == pnpmStoreDirName {
+			if end == len(parts) {
+				return "" // scope or store directory itself
+			}
+			end++
+		}
+		return filepath.FromSlash(strings.Join(parts[:end], "/"))
+	}
+	return ""
+}
+
+func (sh *Shrinker) isLockfile(path string) bool {
+	for _, name := range lockfileNames {
+		if path == filepath.Join(sh.projectPath(), filepath.FromSlash(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns roots of packages which own provided pathes. Pathes outside of checking directory and lockfiles
+// are skipped. Nested packages are skipped too, if their parent package is in result, because it's walked
+// with all nested entries
+func (sh *Shrinker) changedPackages(pathes []string) []string {
+	roots := make(map[string]struct{})
+	for _, path := range pathes {
+		if sh.isLockfile(path) {
+			continue
+		}
+
+		root := packageRootFromPath(path)
+		if root == "" || !strings.HasPrefix(root, sh.checkPath+string(filepath.Separator)) {
+			continue
+		}
+		roots[root] = struct{}{}
+	}
+
+	packages := make([]string, 0, len(roots))
+	for _, root := range sortedSet(roots) {
+		if len(packages) != 0 && strings.HasPrefix(root, packages[len(packages)-1]+string(filepath.Separator)) {
+			continue // sorted order places nested packages right after their parent
+		}
+		packages = append(packages, root)
+	}
+	return packages
+}
+
+// Returns pathes of packages which were added or changed their version according to lockfiles
+func (sh *Shrinker) lockfileChanges(before, after *lockfile) []string {
+	pathes := make([]string, 0)
+	for key, pkg := range after.packages {
+		if key == "" {
+			continue
+		}
+
+		if before != nil {
+			if previous, exists := before.packages[key]; exists && previous.Version == pkg.Version {
+				continue
+			}
+		}
+		pathes = append(pathes, filepath.Join(sh.projectPath(), filepath.FromSlash(key)))
+	}
+	return pathes
+}
+
+// Checks is directory the checking one or inside of it and isn't protected or removed with one of its parents
+func (sh *Shrinker) isCleanableDir(ctx context.Context, dir string) bool {
+	relPath, err := filepath.Rel(sh.checkPath, dir)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	if relPath == "." {
+		return pathExists(sh.fs, dir)
+	}
+
+	current := sh.checkPath
+	for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		stat, err := sh.fs.Stat(current, false)
+		if err != nil {
+			return false
+		}
+		if current == dir {
+			return true
+		}
+
+		match := sh.match(ctx, current, NewFileInfoWithStat(part, stat.Mode(), stat.Size(), stat.ModTime()))
+		if match.Removes() || match.Excludes() {
+			return false
+		}
+	}
+	return true
+}
+
+// Removes entries matched by rules only inside provided directories, e.g. packages changed by install.
+// Directories outside of checking directory, missing ones and ones protected by exclude rules of parents are skipped
+func (sh *Shrinker) CleanDirs(ctx context.Context, dirs []string) *FileStat {
+	return sh.clean(ctx, sh.cleanableDirs(ctx, dirs)...)
+}
+
+func (sh *Shrinker) cleanableDirs(ctx context.Context, dirs []string) []string {
+	cleanable := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if sh.isCleanableDir(ctx, dir) {
+			cleanable = append(cleanable, dir)
+		}
+	}
+	return cleanable
+}
+
+// Returns pathes which still exist. Events of removed entries don't need shrinking, most of them
+// are caused by shrinking itself and would start another pass for the same packages
+func (sh *Shrinker) existingPathes(pathes []string) []string {
+	existing := make([]string, 0, len(pathes))
+	for _, path := range pathes {
+		if pathExists(sh.fs, path) {
+			existing = append(existing, path)
+		}
+	}
+	return existing
+}
+
+// Shrinks packages changed in every batch of changed pathes, e.g. collected from file system events
+// during install. Lockfiles are compared with their previous state, so packages replaced without
+// noticed events are shrunk too. Pathes which don't exist anymore are ignored and fn is called only
+// when some package is cleaned. Returns when batches channel is closed or context is done
+func (sh *Shrinker) Watch(ctx context.Context, batches <-chan []string, fn func(result *WatchResult)) {
+	projectPath := sh.projectPath()
+	lock, err := sh.readLockfile(projectPath)
+	if err != nil {
+		sh.logger.Debug("no lockfile to compare", "path", projectPath, "error", err)
+	}
+
+	for {
+		var batch []string
+		var isOpen bool
+		select {
+		case batch, isOpen = <-batches:
+			if !isOpen {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		changed := sh.existingPathes(batch)
+		for _, path := range batch {
+			if !sh.isLockfile(path) {
+				continue
+			}
+
+			newLock, err := sh.readLockfile(projectPath)
+			if err != nil {
+				sh.logger.Warn("fail read lockfile", "path", projectPath, "error", err)
+				break
+			}
+			changed = append(changed, sh.lockfileChanges(lock, newLock)...)
+			lock = newLock
+			break
+		}
+
+		packages := sh.cleanableDirs(ctx, sh.changedPackages(changed))
+		if len(packages) == 0 {
+			continue
+		}
+
+		sh.logger.Debug("shrinking changed packages", "count", len(packages))
+		stats := sh.clean(ctx, packages...)
+		fn(&WatchResult{Packages: packages, Stats: stats})
+	}
+}
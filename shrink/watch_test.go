@@ -0,0 +1,170 @@
+package shrink
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/icecream78/node_shrinker/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWatchedDirFunc(t *testing.T) {
+	testCases := []struct {
+		dir  string
+		want bool
+	}{
+		{"/project/node_modules", true},
+		{"/project/node_modules/a", true},
+		{"/project/node_modules/@scope", true},
+		{"/project/node_modules/@scope/a", true},
+		{"/project/node_modules/.pnpm", true},
+		{"/project/node_modules/.pnpm/a@1.0.0", true},
+		{"/project/node_modules/a/lib", false},
+		{"/project/node_modules/@scope/a/lib", false},
+		{"/project/src", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, IsWatchedDir(tc.dir), fmt.Sprintf("Input: %s", tc.dir))
+	}
+}
+
+func TestChangedPackagesFunc(t *testing.T) {
+	sh := newMemShrinker(t, fs.NewMemFS("/project", newMemTree()), &Config{})
+
+	testCases := []struct {
+		alias  string
+		pathes []string
+		want   []string
+	}{
+		{
+			"Test files of one package",
+			[]string{"/project/node_modules/a/index.js", "/project/node_modules/a/lib/x.js", "/project/node_modules/a"},
+			[]string{"/project/node_modules/a"},
+		},
+		{
+			"Test scoped and pnpm packages",
+			[]string{"/project/node_modules/@scope/b/index.js", "/project/node_modules/.pnpm/c@1.0.0/node_modules/c/index.js"},
+			[]string{"/project/node_modules/.pnpm/c@1.0.0", "/project/node_modules/@scope/b"},
+		},
+		{
+			"Test nested package of changed one",
+			[]string{"/project/node_modules/a/node_modules/b/index.js", "/project/node_modules/a/package.json"},
+			[]string{"/project/node_modules/a"},
+		},
+		{
+			"Test only nested package changed",
+			[]string{"/project/node_modules/a/node_modules/b/index.js"},
+			[]string{"/project/node_modules/a/node_modules/b"},
+		},
+		{
+			"Test lockfiles, scopes and pathes outside of checking directory",
+			[]string{"/project/node_modules/.package-lock.json", "/project/package-lock.json", "/project/node_modules/@scope", "/project/src/index.js", "/project/node_modules"},
+			[]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, sh.changedPackages(tc.pathes))
+		})
+	}
+}
+
+func TestWatchFunc(t *testing.T) {
+	tree := newMemTree()
+	tree["node_modules/c/test/c.spec.js"] = fs.MemFile("0123456789")
+	tree["node_modules/c/index.ts"] = fs.MemFile("c")
+	tree["node_modules/keep/test/k.spec.js"] = fs.MemFile("k")
+	tree["node_modules/.package-lock.json"] = fs.MemFile(`{"lockfileVersion": 3, "packages": {"node_modules/b": {"version": "1.0.0"}}}`)
+	memFS := fs.NewMemFS("/project", tree)
+
+	sh := newMemShrinker(t, memFS, &Config{ExcludeNames: []string{"keep"}})
+
+	batches := make(chan []string)
+	results := make([]*WatchResult, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sh.Watch(context.TODO(), batches, func(result *WatchResult) {
+			results = append(results, result)
+		})
+	}()
+
+	// excluded package isn't shrunk, changes outside of packages are ignored
+	batches <- []string{"/project/node_modules/a/index.js", "/project/node_modules/keep/test/k.spec.js", "/project/src/index.js"}
+	batches <- []string{"/project/package.json"}
+
+	// c was installed without noticed events, it's found by lockfile
+	assert.Nil(t, memFS.WriteFile("/project/node_modules/.package-lock.json", []byte(
+		`{"lockfileVersion": 3, "packages": {"node_modules/b": {"version": "1.0.0"}, "node_modules/c": {"version": "1.0.0"}}}`,
+	)))
+	batches <- []string{"/project/node_modules/.package-lock.json"}
+	close(batches)
+	<-done
+
+	if assert.Equal(t, 2, len(results)) {
+		assert.Equal(t, []string{"/project/node_modules/a", "/project/node_modules/keep"}, results[0].Packages)
+		assert.Equal(t, int64(len("export {}")+len("# a")+20), results[0].Stats.Size())
+
+		assert.Equal(t, []string{"/project/node_modules/c"}, results[1].Packages)
+		assert.Equal(t, int64(2), results[1].Stats.FilesCount())
+	}
+
+	for _, path := range []string{"/project/node_modules/a/test", "/project/node_modules/c/test", "/project/node_modules/c/index.ts"} {
+		assert.False(t, pathExists(memFS, path), path)
+	}
+	for _, path := range []string{"/project/node_modules/keep/test", "/project/node_modules/b/lib/main.ts"} {
+		assert.True(t, pathExists(memFS, path), path)
+	}
+}
+
+func TestWatchRemovedPathesFunc(t *testing.T) {
+	tree := newMemTree()
+	tree["node_modules/c/test/c.spec.js"] = fs.MemFile("c")
+	memFS := fs.NewMemFS("/project", tree)
+	sh := newMemShrinker(t, memFS, &Config{})
+
+	batches := make(chan []string)
+	results := make([]*WatchResult, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sh.Watch(context.TODO(), batches, func(result *WatchResult) {
+			results = append(results, result)
+		})
+	}()
+
+	batches <- []string{"/project/node_modules/a/index.js"}
+	// events of entries removed by previous pass and of removed packages don't start another one
+	batches <- []string{"/project/node_modules/a/test/a.spec.js", "/project/node_modules/a/test", "/project/node_modules/a/README.md"}
+	assert.Nil(t, memFS.RemoveAll("/project/node_modules/c"))
+	batches <- []string{"/project/node_modules/c/test/c.spec.js", "/project/node_modules/c"}
+	close(batches)
+	<-done
+
+	if assert.Equal(t, 1, len(results)) {
+		assert.Equal(t, []string{"/project/node_modules/a"}, results[0].Packages)
+	}
+}
+
+func TestCleanDirsFunc(t *testing.T) {
+	tree := newMemTree()
+	tree["node_modules/b/keep/test/x.js"] = fs.MemFile("x")
+	tree["node_modules/a/test/node_modules/c/test/y.js"] = fs.MemFile("y")
+	memFS := fs.NewMemFS("/project", tree)
+
+	sh := newMemShrinker(t, memFS, &Config{ExcludeNames: []string{"keep"}})
+	stats := sh.CleanDirs(context.TODO(), []string{
+		"/project/node_modules/b/keep",                // excluded
+		"/project/node_modules/a/test/node_modules/c", // inside of removed directory
+		"/project/node_modules/missing",
+		"/project/src",
+		"/project/node_modules",
+	})
+
+	// only checking directory itself is cleaned
+	assert.Equal(t, int64(len("export {}")+len("# a")+20+1), stats.Size())
+	assert.True(t, pathExists(memFS, "/project/node_modules/b/keep/test/x.js"))
+}
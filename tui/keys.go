@@ -0,0 +1,92 @@
+package tui
+
+type Key int
+
+const (
+	KeyUnknown Key = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyToggle
+	KeyToggleAll
+	KeyEnter
+	KeyYes
+	KeyNo
+	KeyQuit
+)
+
+var escapeSequences map[string]Key = map[string]Key{
+	"\x1b[A":  KeyUp,
+	"\x1bOA":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1bOB":  KeyDown,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+	"\x1b[H":  KeyHome,
+	"\x1bOH":  KeyHome,
+	"\x1b[1~": KeyHome,
+	"\x1b[F":  KeyEnd,
+	"\x1bOF":  KeyEnd,
+	"\x1b[4~": KeyEnd,
+}
+
+var singleKeys map[byte]Key = map[byte]Key{
+	'k':    KeyUp,
+	'j':    KeyDown,
+	'g':    KeyHome,
+	'G':    KeyEnd,
+	' ':    KeyToggle,
+	'a':    KeyToggleAll,
+	'\r':   KeyEnter,
+	'\n':   KeyEnter,
+	'y':    KeyYes,
+	'Y':    KeyYes,
+	'n':    KeyNo,
+	'N':    KeyNo,
+	'q':    KeyQuit,
+	'\x1b': KeyQuit, // standalone escape
+	'\x03': KeyQuit, // ctrl+c, raw mode doesn't produce signals
+}
+
+// Parses raw terminal input into list of keys. Single read may contain several keys
+func parseKeys(input []byte) []Key {
+	keys := make([]Key, 0, len(input))
+	for i := 0; i < len(input); {
+		if input[i] == '\x1b' && i+1 < len(input) {
+			matched := false
+			for seq, key := range escapeSequences {
+				if len(input[i:]) >= len(seq) && string(input[i:i+len(seq)]) == seq {
+					keys = append(keys, key)
+					i += len(seq)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+
+			if input[i+1] == '[' || input[i+1] == 'O' {
+				// skip unsupported sequence (e.g. left/right arrows) up to its final byte
+				j := i + 2
+				for j < len(input) && (input[j] < 0x40 || input[j] > 0x7e) {
+					j++
+				}
+				keys = append(keys, KeyUnknown)
+				i = j + 1
+				continue
+			}
+		}
+
+		if key, exists := singleKeys[input[i]]; exists {
+			keys = append(keys, key)
+		} else {
+			keys = append(keys, KeyUnknown)
+		}
+		i++
+	}
+	return keys
+}
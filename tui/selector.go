@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+	color "github.com/logrusorgru/aurora"
+)
+
+type Entry struct {
+	Title    string
+	Size     int64
+	Selected bool
+	Value    interface{} // any user data, returned back with selected entries
+}
+
+type Group struct {
+	Title   string
+	Entries []*Entry
+}
+
+func (g *Group) Size() (size int64) {
+	for _, entry := range g.Entries {
+		size += entry.Size
+	}
+	return
+}
+
+func (g *Group) selectedCount() (count int) {
+	for _, entry := range g.Entries {
+		if entry.Selected {
+			count++
+		}
+	}
+	return
+}
+
+type selectorState int
+
+const (
+	browsing selectorState = iota
+	confirming
+	confirmed
+	cancelled
+)
+
+const helpLine string = "↑/↓ move  space toggle  a toggle all  enter remove selected  q quit"
+
+// one line of the list, entry is nil for group header
+type selectorRow struct {
+	group *Group
+	entry *Entry
+}
+
+// Selector is a list of groups with entries, which user can browse and toggle
+type Selector struct {
+	title    string
+	groups   []*Group
+	rows     []selectorRow
+	cursor   int
+	offset   int
+	pageSize int
+	state    selectorState
+}
+
+// Creates selector. Groups and their entries are sorted by size from the biggest one
+func NewSelector(title string, groups []*Group) *Selector {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Size() > groups[j].Size()
+	})
+
+	rows := make([]selectorRow, 0)
+	for _, group := range groups {
+		entries := group.Entries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Size > entries[j].Size
+		})
+
+		rows = append(rows, selectorRow{group: group})
+		for _, entry := range entries {
+			rows = append(rows, selectorRow{group: group, entry: entry})
+		}
+	}
+
+	return &Selector{
+		title:    title,
+		groups:   groups,
+		rows:     rows,
+		pageSize: 10,
+	}
+}
+
+func (s *Selector) Done() bool {
+	return s.state == confirmed || s.state == cancelled
+}
+
+func (s *Selector) Confirmed() bool {
+	return s.state == confirmed
+}
+
+func (s *Selector) Selected() []*Entry {
+	selected := make([]*Entry, 0)
+	for _, group := range s.groups {
+		for _, entry := range group.Entries {
+			if entry.Selected {
+				selected = append(selected, entry)
+			}
+		}
+	}
+	return selected
+}
+
+func (s *Selector) SelectedSize() (count int, size int64) {
+	for _, entry := range s.Selected() {
+		count++
+		size += entry.Size
+	}
+	return
+}
+
+func (s *Selector) TotalSize() (size int64) {
+	for _, group := range s.groups {
+		size += group.Size()
+	}
+	return
+}
+
+func (s *Selector) HandleKey(key Key) {
+	if s.state == confirming {
+		switch key {
+		case KeyYes:
+			s.state = confirmed
+		case KeyNo, KeyQuit:
+			s.state = browsing
+		}
+		return
+	}
+
+	switch key {
+	case KeyUp:
+		s.moveCursor(-1)
+	case KeyDown:
+		s.moveCursor(1)
+	case KeyPageUp:
+		s.moveCursor(-s.pageSize)
+	case KeyPageDown:
+		s.moveCursor(s.pageSize)
+	case KeyHome:
+		s.moveCursor(-len(s.rows))
+	case KeyEnd:
+		s.moveCursor(len(s.rows))
+	case KeyToggle:
+		s.toggleCurrent()
+	case KeyToggleAll:
+		s.toggleAll()
+	case KeyEnter:
+		if count, _ := s.SelectedSize(); count != 0 {
+			s.state = confirming
+		}
+	case KeyQuit:
+		s.state = cancelled
+	}
+}
+
+func (s *Selector) moveCursor(delta int) {
+	s.cursor += delta
+	if s.cursor >= len(s.rows) {
+		s.cursor = len(s.rows) - 1
+	}
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+}
+
+func (s *Selector) toggleCurrent() {
+	if len(s.rows) == 0 {
+		return
+	}
+
+	row := s.rows[s.cursor]
+	if row.entry != nil {
+		row.entry.Selected = !row.entry.Selected
+		return
+	}
+
+	// group header toggles all group entries at once
+	selectAll := row.group.selectedCount() != len(row.group.Entries)
+	for _, entry := range row.group.Entries {
+		entry.Selected = selectAll
+	}
+}
+
+func (s *Selector) toggleAll() {
+	count, _ := s.SelectedSize()
+	selectAll := count != len(s.rows)-len(s.groups)
+	for _, group := range s.groups {
+		for _, entry := range group.Entries {
+			entry.Selected = selectAll
+		}
+	}
+}
+
+// Renders selector into string which fits provided terminal size
+func (s *Selector) Render(width, height int) string {
+	listHeight := height - 4 // title, help, empty line and status
+	if listHeight < 1 {
+		listHeight = 1
+	}
+	s.pageSize = listHeight
+
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+listHeight {
+		s.offset = s.cursor - listHeight + 1
+	}
+
+	lines := make([]string, 0, height)
+	lines = append(lines, fmt.Sprint(color.Bold(truncate(s.title, width))))
+	lines = append(lines, fmt.Sprint(color.Gray(12, truncate(helpLine, width))))
+	lines = append(lines, "")
+
+	if len(s.rows) == 0 {
+		lines = append(lines, fmt.Sprint(color.Yellow("nothing matched")))
+	}
+
+	for i := s.offset; i < len(s.rows) && i < s.offset+listHeight; i++ {
+		lines = append(lines, s.renderRow(s.rows[i], i == s.cursor, width))
+	}
+
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	count, size := s.SelectedSize()
+	var status string
+	if s.state == confirming {
+		status = fmt.Sprint(color.Red(truncate(fmt.Sprintf("Remove %d entries and release %s? [y/N]", count, humanize.Bytes(uint64(size))), width)))
+	} else {
+		status = fmt.Sprintf("selected: %d entries, %v of %v", count, color.Cyan(humanize.Bytes(uint64(size))), humanize.Bytes(uint64(s.TotalSize())))
+	}
+	lines = append(lines, status)
+
+	// terminal is in raw mode, so carriage return is required explicitly
+	return strings.Join(lines, "\r\n")
+}
+
+func (s *Selector) renderRow(row selectorRow, isCursor bool, width int) string {
+	var line string
+	var selected bool
+
+	if row.entry == nil {
+		mark := "[ ]"
+		switch row.group.selectedCount() {
+		case len(row.group.Entries):
+			mark = "[x]"
+			selected = true
+		case 0:
+		default:
+			mark = "[-]"
+		}
+		line = fmt.Sprintf("%s %s (%s)", mark, row.group.Title, humanize.Bytes(uint64(row.group.Size())))
+	} else {
+		mark := "[ ]"
+		if row.entry.Selected {
+			mark = "[x]"
+			selected = true
+		}
+		line = fmt.Sprintf("    %s %s (%s)", mark, row.entry.Title, humanize.Bytes(uint64(row.entry.Size)))
+	}
+
+	line = truncate(line, width)
+	switch {
+	case isCursor:
+		return fmt.Sprint(color.Reverse(line))
+	case selected:
+		return fmt.Sprint(color.Green(line))
+	case row.entry == nil:
+		return fmt.Sprint(color.Yellow(line))
+	}
+	return line
+}
+
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if width <= 0 || len(runes) <= width {
+		return line
+	}
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
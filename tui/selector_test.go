@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSelector() *Selector {
+	return NewSelector("test", []*Group{
+		{Title: "small", Entries: []*Entry{
+			{Title: "small/test", Size: 10},
+		}},
+		{Title: "big", Entries: []*Entry{
+			{Title: "big/docs", Size: 100},
+			{Title: "big/test", Size: 1000},
+		}},
+	})
+}
+
+func TestSelectorSortingFunc(t *testing.T) {
+	s := newTestSelector()
+
+	titles := make([]string, 0)
+	for _, row := range s.rows {
+		if row.entry == nil {
+			titles = append(titles, row.group.Title)
+		} else {
+			titles = append(titles, row.entry.Title)
+		}
+	}
+
+	assert.Equal(t, []string{"big", "big/test", "big/docs", "small", "small/test"}, titles)
+}
+
+func TestSelectorToggleFunc(t *testing.T) {
+	testCases := []struct {
+		alias     string
+		keys      []Key
+		wantCount int
+		wantSize  int64
+	}{
+		{"Test nothing selected initially", []Key{}, 0, 0},
+		{"Test toggle group header", []Key{KeyToggle}, 2, 1100},
+		{"Test toggle group header twice", []Key{KeyToggle, KeyToggle}, 0, 0},
+		{"Test toggle single entry", []Key{KeyDown, KeyToggle}, 1, 1000},
+		{"Test toggle all", []Key{KeyToggleAll}, 3, 1110},
+		{"Test toggle all after partial selection", []Key{KeyEnd, KeyToggle, KeyToggleAll}, 3, 1110},
+		{"Test cursor doesn't leave list", []Key{KeyUp, KeyToggle}, 2, 1100},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			s := newTestSelector()
+			for _, key := range tc.keys {
+				s.HandleKey(key)
+			}
+
+			count, size := s.SelectedSize()
+			assert.Equal(t, tc.wantCount, count, fmt.Sprintf("Keys: %v", tc.keys))
+			assert.Equal(t, tc.wantSize, size, fmt.Sprintf("Keys: %v", tc.keys))
+		})
+	}
+}
+
+func TestSelectorConfirmFunc(t *testing.T) {
+	testCases := []struct {
+		alias         string
+		keys          []Key
+		wantDone      bool
+		wantConfirmed bool
+	}{
+		{"Test enter without selection is ignored", []Key{KeyEnter, KeyYes}, false, false},
+		{"Test confirm selection", []Key{KeyToggle, KeyEnter, KeyYes}, true, true},
+		{"Test decline confirmation", []Key{KeyToggle, KeyEnter, KeyNo}, false, false},
+		{"Test quit", []Key{KeyToggle, KeyQuit}, true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			s := newTestSelector()
+			for _, key := range tc.keys {
+				s.HandleKey(key)
+			}
+
+			assert.Equal(t, tc.wantDone, s.Done())
+			assert.Equal(t, tc.wantConfirmed, s.Confirmed())
+		})
+	}
+}
+
+func TestParseKeysFunc(t *testing.T) {
+	testCases := []struct {
+		alias string
+		input string
+		want  []Key
+	}{
+		{"Test arrows", "\x1b[A\x1b[B", []Key{KeyUp, KeyDown}},
+		{"Test vim keys", "jk", []Key{KeyDown, KeyUp}},
+		{"Test standalone escape", "\x1b", []Key{KeyQuit}},
+		{"Test unsupported sequence doesn't quit", "\x1b[C ", []Key{KeyUnknown, KeyToggle}},
+		{"Test page keys", "\x1b[5~\x1b[6~", []Key{KeyPageUp, KeyPageDown}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			assert.Equal(t, tc.want, parseKeys([]byte(tc.input)), fmt.Sprintf("Input: %q", tc.input))
+		})
+	}
+}
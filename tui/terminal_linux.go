@@ -0,0 +1,58 @@
+//go:build linux
+// +build linux
+
+package tui
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Switches terminal into raw mode. Returned function restores previous terminal state
+func makeRaw(fd int) (func() error, error) {
+	var oldState syscall.Termios
+	if err := ioctl(fd, syscall.TCGETS, uintptr(unsafe.Pointer(&oldState))); err != nil {
+		return nil, err
+	}
+
+	newState := oldState
+	newState.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	newState.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	newState.Cflag &^= syscall.CSIZE | syscall.PARENB
+	newState.Cflag |= syscall.CS8
+	newState.Cc[syscall.VMIN] = 1
+	newState.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(fd, syscall.TCSETS, uintptr(unsafe.Pointer(&newState))); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return ioctl(fd, syscall.TCSETS, uintptr(unsafe.Pointer(&oldState)))
+	}, nil
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	var ws struct {
+		Row    uint16
+		Col    uint16
+		Xpixel uint16
+		Ypixel uint16
+	}
+	if err = ioctl(fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+func isTerminal(fd int) bool {
+	var state syscall.Termios
+	return ioctl(fd, syscall.TCGETS, uintptr(unsafe.Pointer(&state))) == nil
+}
+
+func ioctl(fd int, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
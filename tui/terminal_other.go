@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package tui
+
+func makeRaw(fd int) (func() error, error) {
+	return nil, NotSupportedError
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	return 0, 0, NotSupportedError
+}
+
+func isTerminal(fd int) bool {
+	return false
+}
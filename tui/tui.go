@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	NotSupportedError = errors.New("interactive mode is supported on Linux only")
+	NotTerminalError  = errors.New("input is not a terminal")
+)
+
+const (
+	enterAltScreen string = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  string = "\x1b[?25h\x1b[?1049l"
+	clearScreen    string = "\x1b[H\x1b[2J"
+
+	defaultWidth  int = 80
+	defaultHeight int = 24
+)
+
+// Checks is file connected to terminal. Always false on platforms without interactive mode support
+func IsTerminal(f *os.File) bool {
+	return isTerminal(int(f.Fd()))
+}
+
+// Runs selector in terminal until user confirms or cancels selection
+func Run(in *os.File, out io.Writer, selector *Selector) error {
+	fd := int(in.Fd())
+	if !isTerminal(fd) {
+		return NotTerminalError
+	}
+
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = restore()
+	}()
+
+	fmt.Fprint(out, enterAltScreen)
+	defer fmt.Fprint(out, exitAltScreen)
+
+	buf := make([]byte, 64)
+	for !selector.Done() {
+		width, height, err := terminalSize(fd)
+		if err != nil || width == 0 || height == 0 {
+			width, height = defaultWidth, defaultHeight
+		}
+		fmt.Fprint(out, clearScreen+selector.Render(width, height))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range parseKeys(buf[:n]) {
+			selector.HandleKey(key)
+			if selector.Done() {
+				break
+			}
+		}
+	}
+	return nil
+}
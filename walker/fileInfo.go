@@ -2,6 +2,8 @@ package walker
 
 import (
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/karrick/godirwalk"
 )
@@ -10,12 +12,21 @@ type FileInfo struct {
 	name      string
 	isDir     bool
 	isRegular bool
+
+	// metadata is read with lstat on first use, so walking isn't slowed down when it's not needed
+	path    string
+	info    os.FileInfo
+	infoErr error
+	loaded  bool
 }
 
 type FileInfoI interface {
 	Name() string
 	IsDir() bool
 	IsRegular() bool
+	Size() int64        // zero if metadata can't be read
+	Mode() os.FileMode  // zero if metadata can't be read
+	ModTime() time.Time // zero time if metadata can't be read
 }
 
 func (w *FileInfo) Name() string {
@@ -30,11 +41,48 @@ func (w *FileInfo) IsRegular() bool {
 	return w.isRegular
 }
 
-func NewFileInfoFromDe(de *godirwalk.Dirent) *FileInfo {
+func (w *FileInfo) load() os.FileInfo {
+	if !w.loaded {
+		w.loaded = true
+		if w.path == "" {
+			return nil
+		}
+		w.info, w.infoErr = os.Lstat(w.path)
+	}
+
+	if w.infoErr != nil {
+		return nil
+	}
+	return w.info
+}
+
+func (w *FileInfo) Size() int64 {
+	if info := w.load(); info != nil {
+		return info.Size()
+	}
+	return 0
+}
+
+func (w *FileInfo) Mode() os.FileMode {
+	if info := w.load(); info != nil {
+		return info.Mode()
+	}
+	return 0
+}
+
+func (w *FileInfo) ModTime() time.Time {
+	if info := w.load(); info != nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+func NewFileInfoFromDe(osPathname string, de *godirwalk.Dirent) *FileInfo {
 	return &FileInfo{
 		name:      de.Name(),
 		isDir:     de.IsDir(),
 		isRegular: de.IsRegular(),
+		path:      osPathname,
 	}
 }
 
@@ -43,5 +91,51 @@ func NewFileInfoFromOsFile(f os.FileInfo) *FileInfo {
 		name:      f.Name(),
 		isDir:     f.IsDir(),
 		isRegular: !f.IsDir(),
+		info:      f,
+		loaded:    true,
 	}
 }
+
+func NewFileInfo(name string, isDir, isRegular bool) *FileInfo {
+	return &FileInfo{
+		name:      name,
+		isDir:     isDir,
+		isRegular: isRegular,
+	}
+}
+
+// Creates file info which reads metadata of provided path on first use
+func NewFileInfoFromPath(osPathname string, isDir, isRegular bool) *FileInfo {
+	return &FileInfo{
+		name:      filepath.Base(osPathname),
+		isDir:     isDir,
+		isRegular: isRegular,
+		path:      osPathname,
+	}
+}
+
+// Creates file info with already known metadata, e.g. for entries of in-memory tree
+func NewFileInfoWithStat(name string, mode os.FileMode, size int64, modTime time.Time) *FileInfo {
+	return &FileInfo{
+		name:      name,
+		isDir:     mode.IsDir(),
+		isRegular: mode.IsRegular(),
+		info:      &fileStat{name: name, mode: mode, size: size, modTime: modTime},
+		loaded:    true,
+	}
+}
+
+// minimal os.FileInfo implementation for NewFileInfoWithStat
+type fileStat struct {
+	name    string
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+}
+
+func (fs *fileStat) Name() string       { return fs.name }
+func (fs *fileStat) Size() int64        { return fs.size }
+func (fs *fileStat) Mode() os.FileMode  { return fs.mode }
+func (fs *fileStat) ModTime() time.Time { return fs.modTime }
+func (fs *fileStat) IsDir() bool        { return fs.mode.IsDir() }
+func (fs *fileStat) Sys() interface{}   { return nil }
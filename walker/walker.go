@@ -38,7 +38,7 @@ func (dw *dirWalker) Walk(filepath string, callback WalkFunc, errCallback WalkEr
 	err := godirwalk.Walk(filepath, &godirwalk.Options{
 		Unsorted: !dw.keepOrder, // for higher speed walking dir tree
 		Callback: func(osPathname string, de *godirwalk.Dirent) error {
-			err := callback(osPathname, NewFileInfoFromDe(de))
+			err := callback(osPathname, NewFileInfoFromDe(osPathname, de))
 
 			// for library copability
 			if err == NotProcessError {
@@ -0,0 +1,85 @@
+package watch
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Collects pathes until there are no new ones during quiet period and sends them as one sorted batch
+// without duplicates. Install writes a lot of files, so packages are processed after it finishes.
+// Pathes are read while batch waits for receiver (e.g. previous batch is being shrunk), so events
+// don't pile up in watcher queue; they are added to the waiting batch. Collected pathes are sent
+// when input channel is closed. Output channel is closed after input one or when context is done
+func Debounce(ctx context.Context, pathes <-chan string, quiet time.Duration) <-chan []string {
+	batches := make(chan []string)
+
+	go func() {
+		defer close(batches)
+
+		timer := time.NewTimer(quiet)
+		stopTimer(timer)
+
+		pending := make(map[string]struct{})
+		ready := make(map[string]struct{}) // quiet period passed, waits for receiver
+		var batch []string                 // sorted content of ready
+		moveToReady := func() {
+			if len(pending) == 0 {
+				return
+			}
+			for path := range pending {
+				ready[path] = struct{}{}
+			}
+			pending = make(map[string]struct{})
+
+			batch = make([]string, 0, len(ready))
+			for path := range ready {
+				batch = append(batch, path)
+			}
+			sort.Strings(batch)
+		}
+
+		for {
+			var out chan<- []string // nil channel disables sending while there is no ready batch
+			if len(batch) != 0 {
+				out = batches
+			}
+
+			select {
+			case path, isOpen := <-pathes:
+				if !isOpen {
+					moveToReady()
+					if len(batch) != 0 {
+						select {
+						case batches <- batch:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+
+				pending[path] = struct{}{}
+				stopTimer(timer)
+				timer.Reset(quiet)
+			case <-timer.C:
+				moveToReady()
+			case out <- batch:
+				ready = make(map[string]struct{})
+				batch = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return batches
+}
+
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebounceFunc(t *testing.T) {
+	pathes := make(chan string)
+	batches := Debounce(context.TODO(), pathes, 50*time.Millisecond)
+
+	pathes <- "/b"
+	pathes <- "/a"
+	pathes <- "/b"
+	assert.Equal(t, []string{"/a", "/b"}, <-batches)
+
+	// pending pathes are sent when input is closed
+	pathes <- "/c"
+	close(pathes)
+	assert.Equal(t, []string{"/c"}, <-batches)
+
+	_, isOpen := <-batches
+	assert.False(t, isOpen)
+}
+
+func TestDebounceCancelFunc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	pathes := make(chan string)
+	batches := Debounce(ctx, pathes, time.Hour)
+
+	pathes <- "/a"
+	cancel()
+
+	_, isOpen := <-batches
+	assert.False(t, isOpen)
+}
+
+func TestDebounceBusyReceiverFunc(t *testing.T) {
+	pathes := make(chan string)
+	batches := Debounce(context.TODO(), pathes, 10*time.Millisecond)
+
+	// receiver is busy with previous batch, pathes are still read and added to waiting batch
+	pathes <- "/b"
+	time.Sleep(30 * time.Millisecond)
+	for _, path := range []string{"/c", "/a", "/b"} {
+		select {
+		case pathes <- path:
+		case <-time.After(time.Second):
+			t.Fatal("pathes aren't read while batch waits for receiver")
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, <-batches)
+
+	close(pathes)
+	_, isOpen := <-batches
+	assert.False(t, isOpen)
+}
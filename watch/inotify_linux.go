@@ -0,0 +1,227 @@
+//go:build linux
+// +build linux
+
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const watchMask uint32 = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_DELETE
+
+// buffer fits a lot of events with names up to NAME_MAX
+const eventsBufferSize = 64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)
+
+type inotifyWatcher struct {
+	fd      int
+	file    *os.File // wraps fd to read it through runtime poller, so Close interrupts reading
+	recurse RecurseFunc
+
+	mu    sync.Mutex
+	dirs  map[int]string // watch descriptors to watched directories
+	isSet map[string]struct{}
+
+	events chan string
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Watches root directory and its subdirectories accepted by recurse function. Directories created
+// later are watched too, their existing entries are reported as events, because they could be
+// written before watch is added
+func New(root string, recurse RecurseFunc) (Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	w := &inotifyWatcher{
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		recurse: recurse,
+		dirs:    make(map[int]string),
+		isSet:   make(map[string]struct{}),
+		events:  make(chan string),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+
+	if err := w.addTree(root, false); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+
+	go w.readEvents()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *inotifyWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+func (w *inotifyWatcher) addWatch(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, exists := w.isSet[dir]; exists {
+		return nil
+	}
+
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, watchMask|syscall.IN_ONLYDIR)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+	w.dirs[wd] = dir
+	w.isSet[dir] = struct{}{}
+	return nil
+}
+
+func (w *inotifyWatcher) removeWatch(wd int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.isSet, w.dirs[wd])
+	delete(w.dirs, wd)
+}
+
+func (w *inotifyWatcher) watchedDir(wd int) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dir, exists := w.dirs[wd]
+	return dir, exists
+}
+
+// Adds watches on directory and its subdirectories accepted by recurse function. With report flag
+// sends pathes of all found entries, because they appeared before watches were added
+func (w *inotifyWatcher) addTree(dir string, report bool) error {
+	if err := w.addWatch(dir); err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if report && !w.send(path) {
+			return nil
+		}
+
+		if entry.IsDir() && w.recurse(path) {
+			if err := w.addTree(path, report); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns false if watcher is closed
+func (w *inotifyWatcher) send(path string) bool {
+	select {
+	case w.events <- path:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// Errors are dropped if previous one isn't received yet, events reading mustn't be blocked by them
+func (w *inotifyWatcher) sendError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+func (w *inotifyWatcher) readEvents() {
+	defer close(w.errors)
+	defer close(w.events)
+
+	buf := make([]byte, eventsBufferSize)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				w.sendError(err)
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			offset = nameStart + int(event.Len)
+			if offset > n {
+				break
+			}
+
+			if !w.handleEvent(event, buf[nameStart:offset]) {
+				return
+			}
+		}
+	}
+}
+
+// Returns false if watcher is closed
+func (w *inotifyWatcher) handleEvent(event *syscall.InotifyEvent, rawName []byte) bool {
+	if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		w.sendError(OverflowError)
+		return true
+	}
+	if event.Mask&syscall.IN_IGNORED != 0 {
+		w.removeWatch(int(event.Wd))
+		return true
+	}
+
+	dir, exists := w.watchedDir(int(event.Wd))
+	if !exists {
+		return true
+	}
+
+	// name is padded with null bytes up to alignment
+	name := string(rawName)
+	for i := 0; i < len(name); i++ {
+		if name[i] == 0 {
+			name = name[:i]
+			break
+		}
+	}
+	path := filepath.Join(dir, name)
+
+	if !w.send(path) {
+		return false
+	}
+
+	isNewDir := event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0
+	if isNewDir && w.recurse(path) {
+		if err := w.addTree(path, true); err != nil && !os.IsNotExist(err) {
+			w.sendError(err)
+		}
+	}
+	return true
+}
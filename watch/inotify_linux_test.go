@@ -0,0 +1,77 @@
+//go:build linux
+// +build linux
+
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Receives events until expected path is found
+func waitEvent(t *testing.T, w Watcher, path string) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-w.Events():
+			if event == path {
+				return
+			}
+		case err := <-w.Errors():
+			t.Fatalf("unexpected error: %v", err)
+		case <-timeout:
+			t.Fatalf("no event for %s", path)
+		}
+	}
+}
+
+func TestInotifyWatcherFunc(t *testing.T) {
+	root, err := ioutil.TempDir("", "watch")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "deep", "ignored"), 0755))
+
+	w, err := New(root, func(dir string) bool {
+		return filepath.Base(dir) != "ignored"
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer w.Close()
+
+	file := filepath.Join(root, "deep", "a.js")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("a"), 0644))
+	waitEvent(t, w, file)
+
+	// entries written to new directory before watch is added are reported too
+	pkg := filepath.Join(root, "pkg")
+	assert.Nil(t, os.MkdirAll(filepath.Join(pkg, "lib"), 0755))
+	nested := filepath.Join(pkg, "lib", "b.js")
+	assert.Nil(t, ioutil.WriteFile(nested, []byte("b"), 0644))
+	waitEvent(t, w, nested)
+
+	// directories rejected by recurse function aren't watched
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(root, "deep", "ignored", "c.js"), []byte("c"), 0644))
+	last := filepath.Join(root, "last.js")
+	assert.Nil(t, ioutil.WriteFile(last, []byte("d"), 0644))
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event := <-w.Events():
+			assert.NotEqual(t, filepath.Join(root, "deep", "ignored", "c.js"), event)
+			done = event == last
+		case <-timeout:
+			t.Fatalf("no event for %s", last)
+		}
+	}
+
+	assert.Nil(t, w.Close())
+	for range w.Events() {
+	}
+}
@@ -0,0 +1,20 @@
+package watch
+
+import (
+	"errors"
+)
+
+var (
+	NotSupportedError error = errors.New("watching file system isn't supported on this platform")
+	OverflowError     error = errors.New("too many file system events, some of them are lost")
+)
+
+// Decides should directory be watched together with its subdirectories
+type RecurseFunc func(dir string) bool
+
+// Watcher reports pathes of entries created, written, moved or removed in watched directories
+type Watcher interface {
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package watch
+
+// Watches root directory and its subdirectories accepted by recurse function
+func New(root string, recurse RecurseFunc) (Watcher, error) {
+	return nil, NotSupportedError
+}